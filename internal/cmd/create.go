@@ -11,7 +11,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/openvex/go-vex/pkg/vex"
+	"github.com/openvex/vexctl/pkg/ctl"
 )
 
 type createOptions struct {
@@ -108,7 +108,7 @@ Examples:
 				return err
 			}
 
-			newDoc := vex.New()
+			newDoc := ctl.NewDocument()
 
 			newDoc.Metadata.Author = opts.Author
 			newDoc.Metadata.AuthorRole = opts.AuthorRole
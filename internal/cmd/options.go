@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/openvex/go-vex/pkg/vex"
+	"github.com/openvex/vexctl/pkg/ctl"
 	"github.com/spf13/cobra"
 )
 
@@ -46,14 +47,14 @@ func (do *vexDocOptions) AddFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(
 		&do.Author,
 		"author",
-		vex.DefaultAuthor,
+		ctl.DefaultAuthor,
 		"author to record in the new document",
 	)
 
 	cmd.PersistentFlags().StringVar(
 		&do.AuthorRole,
 		"author-role",
-		vex.DefaultRole,
+		ctl.DefaultAuthorRole,
 		"optional author role to record in the new document",
 	)
 }
@@ -171,7 +172,7 @@ func (so *vexStatementOptions) AddFlags(cmd *cobra.Command) {
 
 // ToStatement returns a new vex.Statement based on the configured options
 func (so *vexStatementOptions) ToStatement() vex.Statement {
-	t := time.Now()
+	t := ctl.TimeSource()
 
 	s := vex.Statement{
 		Vulnerability: vex.Vulnerability{
@@ -270,7 +271,7 @@ func (of *outFileOption) Validate() error {
 }
 
 func timeFromEnv() (time.Time, error) {
-	t := time.Now()
+	t := ctl.TimeSource()
 	nt, err := vex.DateFromEnv()
 	if err != nil {
 		return t, fmt.Errorf("reading SOURCE_DATE_EPOCH from env: %w", err)
@@ -293,6 +294,7 @@ func writeDocument(doc *vex.VEX, filepath string) error {
 		defer f.Close()
 	}
 
+	ctl.FillDefaults(doc)
 	if err := doc.ToJSON(out); err != nil {
 		return fmt.Errorf("writing new VEX document: %w", err)
 	}
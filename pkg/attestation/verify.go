@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// Identity captures an identity bound to a signature, for example the subject
+// alternative name of a Fulcio certificate and the OIDC issuer that vouched
+// for it.
+type Identity struct {
+	Subject string
+	Issuer  string
+}
+
+// Verifier abstracts the cryptographic verification of an attestation's
+// signature. Implementations check the signature data and return the
+// identities bound to the signing certificate.
+type Verifier interface {
+	Verify(*SignatureData) ([]Identity, error)
+}
+
+// VerifyAuthor checks that the Author of the VEX document wrapped in the
+// attestation matches one of the identities bound to its signature. Email
+// authors may be expressed as mailto: URIs.
+func (att *Attestation) VerifyAuthor(verifier Verifier) error {
+	if !att.Signed || att.SignatureData == nil {
+		return errors.New("attestation is not signed")
+	}
+
+	author := normalizeIdentity(att.Predicate.Author)
+	if author == "" {
+		return errors.New("VEX document has no author")
+	}
+
+	identities, err := verifier.Verify(att.SignatureData)
+	if err != nil {
+		return fmt.Errorf("verifying signature: %w", err)
+	}
+
+	for _, id := range identities {
+		if normalizeIdentity(id.Subject) == author {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("document author %q does not match the signature identity", att.Predicate.Author)
+}
+
+// normalizeIdentity trims the pieces of an identity string that can vary
+// without changing the identity.
+func normalizeIdentity(id string) string {
+	return strings.TrimPrefix(strings.TrimSpace(id), "mailto:")
+}
+
+// TrustPolicy defines which signatures are trusted when loading signed VEX
+// documents. A signature is trusted when verification succeeds and one of its
+// identities is listed in Identities. Identities with an empty Issuer match
+// certificates from any issuer.
+type TrustPolicy struct {
+	// Verifier checks the signature and returns its identities
+	Verifier Verifier
+
+	// Identities lists the trusted signer identities
+	Identities []Identity
+}
+
+// trusts returns true if the policy lists the identity
+func (tp *TrustPolicy) trusts(id Identity) bool {
+	for _, trusted := range tp.Identities {
+		if normalizeIdentity(trusted.Subject) != normalizeIdentity(id.Subject) {
+			continue
+		}
+		if trusted.Issuer == "" || trusted.Issuer == id.Issuer {
+			return true
+		}
+	}
+	return false
+}
+
+// SignedPayload returns the signed envelope of the attestation
+func (sd *SignatureData) SignedPayload() []byte {
+	return sd.signedPayload
+}
+
+// LoadVerified reads a signed VEX attestation from path, verifies its
+// signature and returns the VEX document only if the signature was made by
+// one of the identities trusted by the policy.
+func LoadVerified(path string, policy TrustPolicy) (*vex.VEX, error) {
+	if policy.Verifier == nil {
+		return nil, errors.New("trust policy has no verifier")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signed attestation: %w", err)
+	}
+
+	env := ssldsse.Envelope{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("unmarshalling envelope: %w", err)
+	}
+	if env.PayloadType != intoto.PayloadType || len(env.Signatures) == 0 {
+		return nil, errors.New("file does not contain a signed in-toto attestation")
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding envelope payload: %w", err)
+	}
+
+	att := &Attestation{}
+	if err := json.Unmarshal(payload, att); err != nil {
+		return nil, fmt.Errorf("unmarshalling attestation: %w", err)
+	}
+	if att.PredicateType != vex.TypeURI {
+		return nil, fmt.Errorf("attestation predicate is not VEX: %q", att.PredicateType)
+	}
+	att.Signed = true
+	att.SignatureData = &SignatureData{signedPayload: data}
+
+	identities, err := policy.Verifier.Verify(att.SignatureData)
+	if err != nil {
+		return nil, fmt.Errorf("verifying signature: %w", err)
+	}
+
+	for _, id := range identities {
+		if policy.trusts(id) {
+			return &att.Predicate, nil
+		}
+	}
+	return nil, errors.New("attestation is not signed by a trusted identity")
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVerifier struct {
+	identities []Identity
+	err        error
+}
+
+func (fv *fakeVerifier) Verify(*SignatureData) ([]Identity, error) {
+	return fv.identities, fv.err
+}
+
+func TestVerifyAuthor(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		author   string
+		signed   bool
+		verifier *fakeVerifier
+		mustErr  bool
+	}{
+		{
+			name:     "matching identity",
+			author:   "jdoe@example.com",
+			signed:   true,
+			verifier: &fakeVerifier{identities: []Identity{{Subject: "jdoe@example.com", Issuer: "https://accounts.google.com"}}},
+		},
+		{
+			name:     "matching mailto identity",
+			author:   "mailto:jdoe@example.com",
+			signed:   true,
+			verifier: &fakeVerifier{identities: []Identity{{Subject: "jdoe@example.com"}}},
+		},
+		{
+			name:     "mismatching identity",
+			author:   "jdoe@example.com",
+			signed:   true,
+			verifier: &fakeVerifier{identities: []Identity{{Subject: "mallory@example.com"}}},
+			mustErr:  true,
+		},
+		{
+			name:     "verification fails",
+			author:   "jdoe@example.com",
+			signed:   true,
+			verifier: &fakeVerifier{err: errors.New("invalid signature")},
+			mustErr:  true,
+		},
+		{
+			name:     "unsigned attestation",
+			author:   "jdoe@example.com",
+			verifier: &fakeVerifier{identities: []Identity{{Subject: "jdoe@example.com"}}},
+			mustErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			att := New()
+			att.Predicate.Author = tc.author
+			if tc.signed {
+				att.Signed = true
+				att.SignatureData = &SignatureData{}
+			}
+			err := att.VerifyAuthor(tc.verifier)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadVerified(t *testing.T) {
+	att := New()
+	att.Predicate.Author = "jdoe@example.com"
+	var b bytes.Buffer
+	require.NoError(t, att.ToJSON(&b))
+
+	env, err := json.Marshal(ssldsse.Envelope{
+		PayloadType: intoto.PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(b.Bytes()),
+		Signatures:  []ssldsse.Signature{{Sig: "c2lnbmF0dXJl"}},
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signed.json")
+	require.NoError(t, os.WriteFile(path, env, os.FileMode(0o644)))
+
+	unsigned := filepath.Join(t.TempDir(), "unsigned.json")
+	require.NoError(t, os.WriteFile(unsigned, b.Bytes(), os.FileMode(0o644)))
+
+	trusted := []Identity{{Subject: "jdoe@example.com", Issuer: "https://accounts.google.com"}}
+	for _, tc := range []struct {
+		name     string
+		path     string
+		verifier *fakeVerifier
+		mustErr  bool
+	}{
+		{
+			name:     "trusted identity",
+			path:     path,
+			verifier: &fakeVerifier{identities: []Identity{{Subject: "jdoe@example.com", Issuer: "https://accounts.google.com"}}},
+		},
+		{
+			name:     "untrusted identity",
+			path:     path,
+			verifier: &fakeVerifier{identities: []Identity{{Subject: "mallory@example.com", Issuer: "https://accounts.google.com"}}},
+			mustErr:  true,
+		},
+		{
+			name:     "untrusted issuer",
+			path:     path,
+			verifier: &fakeVerifier{identities: []Identity{{Subject: "jdoe@example.com", Issuer: "https://evil.example.com"}}},
+			mustErr:  true,
+		},
+		{
+			name:     "verification fails",
+			path:     path,
+			verifier: &fakeVerifier{err: errors.New("invalid signature")},
+			mustErr:  true,
+		},
+		{
+			name:     "unsigned document",
+			path:     unsigned,
+			verifier: &fakeVerifier{identities: trusted},
+			mustErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := LoadVerified(tc.path, TrustPolicy{Verifier: tc.verifier, Identities: trusted})
+			if tc.mustErr {
+				require.Error(t, err)
+				require.Nil(t, doc)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "jdoe@example.com", doc.Author)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// DefaultAliasConcurrency is the number of identifiers EnrichAliases
+// resolves at the same time when the options don't set it
+const DefaultAliasConcurrency = 4
+
+// AliasSource is an external service that knows the aliases of
+// vulnerabilities, such as OSV. Aliases returns the other identifiers of a
+// vulnerability, or an empty list if it has none.
+type AliasSource interface {
+	Aliases(vulnID string) ([]string, error)
+}
+
+// AliasCache keeps the aliases resolved by EnrichAliases so they are not
+// looked up again. A cache can be shared by any number of enrichments,
+// including concurrent ones. The zero value is an empty cache.
+type AliasCache struct {
+	mu      sync.Mutex
+	aliases map[string][]string
+}
+
+func (c *AliasCache) get(id string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	aliases, ok := c.aliases[id]
+	return aliases, ok
+}
+
+func (c *AliasCache) set(id string, aliases []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aliases == nil {
+		c.aliases = map[string][]string{}
+	}
+	c.aliases[id] = aliases
+}
+
+// EnrichOptions control how EnrichAliases queries the alias source
+type EnrichOptions struct {
+	// Concurrency is the maximum number of lookups running at the same
+	// time, defaults to DefaultAliasConcurrency. Keeping it low helps
+	// staying under the rate limits of remote services.
+	Concurrency int
+
+	// Cache keeps the resolved aliases across enrichments. When not set,
+	// a cache is only kept for the duration of the call.
+	Cache *AliasCache
+}
+
+// EnrichAliases adds the aliases known to a source to the vulnerabilities in
+// a document. The distinct vulnerability names are collected first and each
+// one is looked up only once, with a bounded number of concurrent lookups,
+// before updating the statements. Lookup errors do not stop the enrichment,
+// they are returned joined once the rest of the statements are updated. Nil
+// options use the defaults.
+func EnrichAliases(doc *vex.VEX, src AliasSource, opts *EnrichOptions) error {
+	if opts == nil {
+		opts = &EnrichOptions{}
+	}
+	cache := opts.Cache
+	if cache == nil {
+		cache = &AliasCache{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultAliasConcurrency
+	}
+
+	pending := []string{}
+	seen := map[string]struct{}{}
+	for i := range doc.Statements {
+		id := string(doc.Statements[i].Vulnerability.Name)
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		if _, ok := cache.get(id); !ok {
+			pending = append(pending, id)
+		}
+	}
+	sort.Strings(pending)
+
+	errs := make([]error, len(pending))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				aliases, err := src.Aliases(pending[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("looking up aliases of %s: %w", pending[i], err)
+					continue
+				}
+				cache.set(pending[i], aliases)
+			}
+		}()
+	}
+	for i := range pending {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for i := range doc.Statements {
+		v := &doc.Statements[i].Vulnerability
+		aliases, ok := cache.get(string(v.Name))
+		if !ok || len(aliases) == 0 {
+			continue
+		}
+		known := vex.Vulnerability{}
+		for _, a := range aliases {
+			known.Aliases = append(known.Aliases, vex.VulnerabilityID(a))
+		}
+		*v = mergeAliases(*v, known)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+type fakeAliasSource struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	aliases map[string][]string
+}
+
+func (f *fakeAliasSource) Aliases(id string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[id]++
+	if id == "CVE-2023-9999" {
+		return nil, errors.New("service unavailable")
+	}
+	return f.aliases[id], nil
+}
+
+func TestEnrichAliases(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := &fakeAliasSource{
+		calls: map[string]int{},
+		aliases: map[string][]string{
+			"CVE-2023-0001": {"GHSA-aaaa-bbbb-cccc"},
+		},
+	}
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/git@1.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+	)
+
+	cache := &AliasCache{}
+	require.NoError(t, EnrichAliases(doc, src, &EnrichOptions{Concurrency: 2, Cache: cache}))
+	require.Equal(t, map[string]int{"CVE-2023-0001": 1, "CVE-2023-0002": 1}, src.calls)
+	require.Equal(t, []vex.VulnerabilityID{"GHSA-aaaa-bbbb-cccc"}, doc.Statements[0].Vulnerability.Aliases)
+	require.Equal(t, []vex.VulnerabilityID{"GHSA-aaaa-bbbb-cccc"}, doc.Statements[1].Vulnerability.Aliases)
+	require.Empty(t, doc.Statements[2].Vulnerability.Aliases)
+
+	// A second enrichment with the same cache does not query the source
+	// again, and aliases are not duplicated
+	require.NoError(t, EnrichAliases(doc, src, &EnrichOptions{Cache: cache}))
+	require.Equal(t, map[string]int{"CVE-2023-0001": 1, "CVE-2023-0002": 1}, src.calls)
+	require.Equal(t, []vex.VulnerabilityID{"GHSA-aaaa-bbbb-cccc"}, doc.Statements[0].Vulnerability.Aliases)
+
+	// Lookup errors are returned after enriching the rest
+	failing := testDocument(ts,
+		testStatement("CVE-2023-9999", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+	)
+	require.ErrorContains(t, EnrichAliases(failing, src, &EnrichOptions{}), "CVE-2023-9999")
+	require.Equal(t, []vex.VulnerabilityID{"GHSA-aaaa-bbbb-cccc"}, failing.Statements[1].Vulnerability.Aliases)
+
+	// Nil options use the defaults
+	require.ErrorContains(t, EnrichAliases(failing, src, nil), "CVE-2023-9999")
+}
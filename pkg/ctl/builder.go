@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// Builder assembles VEX documents through a chain of calls:
+//
+//	doc, err := ctl.NewBuilder().
+//		Author("Jane Doe").
+//		NotAffected("CVE-2023-1234", "pkg:apk/wolfi/bash@1.0.0", vex.ComponentNotPresent).
+//		Affected("CVE-2023-5678", "pkg:apk/wolfi/bash@1.0.0", "Upgrade to 1.0.1").
+//		Build()
+//
+// Each method checks its inputs. Errors are collected and returned by Build,
+// which also validates the complete document.
+type Builder struct {
+	doc  vex.VEX
+	errs []error
+}
+
+// NewBuilder returns a new document builder. Documents get the values set by
+// NewDocument by default: the current context, the TimeSource timestamp and
+// the DefaultAuthor and DefaultAuthorRole.
+func NewBuilder() *Builder {
+	return &Builder{doc: NewDocument()}
+}
+
+// ID sets the document identifier. If no identifier is set, Build generates
+// a canonical one from the document contents.
+func (b *Builder) ID(id string) *Builder {
+	if id == "" {
+		b.errs = append(b.errs, errors.New("document id cannot be empty"))
+	}
+	b.doc.ID = id
+	return b
+}
+
+// Author sets the document author
+func (b *Builder) Author(author string) *Builder {
+	if author == "" {
+		b.errs = append(b.errs, errors.New("document author cannot be empty"))
+	}
+	b.doc.Author = author
+	return b
+}
+
+// AuthorRole sets the role of the document author
+func (b *Builder) AuthorRole(role string) *Builder {
+	b.doc.AuthorRole = role
+	return b
+}
+
+// NotAffected adds a statement declaring a product not affected by a
+// vulnerability
+func (b *Builder) NotAffected(vuln, product string, justification vex.Justification) *Builder {
+	if !justification.Valid() {
+		b.errs = append(b.errs, fmt.Errorf("invalid justification %q for %s", justification, vuln))
+	}
+	return b.statement(vuln, product, vex.Statement{
+		Status: vex.StatusNotAffected, Justification: justification,
+	})
+}
+
+// Affected adds a statement declaring a product affected by a vulnerability.
+// Affected statements require an action statement.
+func (b *Builder) Affected(vuln, product, action string) *Builder {
+	if action == "" {
+		b.errs = append(b.errs, fmt.Errorf("affected statement for %s requires an action statement", vuln))
+	}
+	return b.statement(vuln, product, vex.Statement{
+		Status: vex.StatusAffected, ActionStatement: action,
+	})
+}
+
+// Fixed adds a statement declaring a vulnerability fixed in a product
+func (b *Builder) Fixed(vuln, product string) *Builder {
+	return b.statement(vuln, product, vex.Statement{Status: vex.StatusFixed})
+}
+
+// UnderInvestigation adds a statement declaring a vulnerability under
+// investigation in a product
+func (b *Builder) UnderInvestigation(vuln, product string) *Builder {
+	return b.statement(vuln, product, vex.Statement{Status: vex.StatusUnderInvestigation})
+}
+
+// statement completes a statement with its vulnerability and product and
+// adds it to the document
+//
+//nolint:gocritic // this IS supposed to copy
+func (b *Builder) statement(vuln, product string, s vex.Statement) *Builder {
+	if vuln == "" {
+		b.errs = append(b.errs, errors.New("statement vulnerability cannot be empty"))
+	}
+	if product == "" {
+		b.errs = append(b.errs, fmt.Errorf("statement product for %s cannot be empty", vuln))
+	}
+	s.Vulnerability = vex.Vulnerability{Name: vex.VulnerabilityID(vuln)}
+	s.Products = []vex.Product{{Component: vex.Component{ID: product}}}
+	b.doc.Statements = append(b.doc.Statements, s)
+	return b
+}
+
+// Build validates and returns the document
+func (b *Builder) Build() (*vex.VEX, error) {
+	errs := append([]error{}, b.errs...)
+	for i := range b.doc.Statements {
+		if err := b.doc.Statements[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("statement #%d: %w", i, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("building document: %w", err)
+	}
+
+	doc := b.doc
+	doc.Statements = append([]vex.Statement{}, b.doc.Statements...)
+	if _, err := doc.GenerateCanonicalID(); err != nil {
+		return nil, fmt.Errorf("generating document id: %w", err)
+	}
+	return &doc, nil
+}
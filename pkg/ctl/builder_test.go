@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestBuilder(t *testing.T) {
+	doc, err := NewBuilder().
+		Author("Jane Doe").
+		AuthorRole("Maintainer").
+		NotAffected("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.ComponentNotPresent).
+		Affected("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", "Upgrade to 1.0.1").
+		Fixed("CVE-2023-0003", "pkg:apk/wolfi/bash@1.0.1").
+		UnderInvestigation("CVE-2023-0004", "pkg:apk/wolfi/bash@1.0.1").
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, "Jane Doe", doc.Author)
+	require.Equal(t, "Maintainer", doc.AuthorRole)
+	require.Equal(t, vex.ContextLocator(), doc.Context)
+	require.NotNil(t, doc.Timestamp)
+	require.NotEmpty(t, doc.ID, "canonical id must be generated")
+	require.Len(t, doc.Statements, 4)
+	require.Equal(t, vex.StatusNotAffected, doc.Statements[0].Status)
+	require.Equal(t, vex.ComponentNotPresent, doc.Statements[0].Justification)
+	require.Equal(t, "Upgrade to 1.0.1", doc.Statements[1].ActionStatement)
+
+	doc, err = NewBuilder().ID("my-doc").Fixed("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.1").Build()
+	require.NoError(t, err)
+	require.Equal(t, "my-doc", doc.ID)
+
+	for _, tc := range []struct {
+		name    string
+		builder *Builder
+	}{
+		{"empty author", NewBuilder().Author("")},
+		{"empty id", NewBuilder().ID("")},
+		{"invalid justification", NewBuilder().NotAffected("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", "cheese")},
+		{"affected without action", NewBuilder().Affected("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", "")},
+		{"empty vulnerability", NewBuilder().Fixed("", "pkg:apk/wolfi/bash@1.0.0")},
+		{"empty product", NewBuilder().Fixed("CVE-2023-0001", "")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.builder.Build()
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+var digestRegexp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// CanonicalJSON returns the canonical serialization of a document: compact
+// JSON with its fields and map keys in a fixed order. Documents with the
+// same data always serialize to the same bytes.
+func CanonicalJSON(doc *vex.VEX) ([]byte, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling document: %w", err)
+	}
+	return data, nil
+}
+
+// Digest returns the hex encoded sha256 digest of the canonical
+// serialization of a document
+func Digest(doc *vex.VEX) (string, error) {
+	data, err := CanonicalJSON(doc)
+	if err != nil {
+		return "", err
+	}
+	return digestBytes(data), nil
+}
+
+// StatementDigest returns the hex encoded sha256 digest of the canonical form
+// of a statement, letting external systems reference a single statement. The
+// products, subcomponents and aliases are sorted and dates converted to UTC
+// before hashing, so statements with the same data in a different order get
+// the same digest. The document timestamp is not cascaded, callers hashing
+// statements without a timestamp should cascade it first.
+func StatementDigest(s vex.Statement) string { //nolint:gocritic // this IS supposed to copy
+	s.Vulnerability.Aliases = slices.Clone(s.Vulnerability.Aliases)
+	slices.Sort(s.Vulnerability.Aliases)
+
+	s.Products = slices.Clone(s.Products)
+	for i := range s.Products {
+		subs := slices.Clone(s.Products[i].Subcomponents)
+		sort.SliceStable(subs, func(a, b int) bool {
+			return canonicalKey(&subs[a].Component, &subs[a]) < canonicalKey(&subs[b].Component, &subs[b])
+		})
+		s.Products[i].Subcomponents = subs
+	}
+	sort.SliceStable(s.Products, func(i, j int) bool {
+		return canonicalKey(&s.Products[i].Component, &s.Products[i]) < canonicalKey(&s.Products[j].Component, &s.Products[j])
+	})
+
+	for _, t := range []**time.Time{&s.Timestamp, &s.LastUpdated, &s.ActionStatementTimestamp} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+	return digestBytes([]byte(statementKey(s)))
+}
+
+// canonicalKey returns the key a product or subcomponent is sorted by in
+// StatementDigest: its identifier (see productIdentifier) followed by its JSON,
+// so entries with the same or no identifier still sort the same way whatever
+// their original order
+func canonicalKey(c *vex.Component, v any) string {
+	id := productIdentifier(&vex.Product{Component: *c})
+	data, err := json.Marshal(v)
+	if err != nil {
+		return id
+	}
+	return id + "\x00" + string(data)
+}
+
+func digestBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// StoreCAS writes the canonical JSON of a document to a content addressable
+// store in dir, as <dir>/<digest>.json, and returns its digest. Storing a
+// document that is already in the store does not write it again.
+func StoreCAS(doc *vex.VEX, dir string) (string, error) {
+	data, err := CanonicalJSON(doc)
+	if err != nil {
+		return "", err
+	}
+	digest := digestBytes(data)
+	path := filepath.Join(dir, digest+".json")
+
+	if _, err := os.Stat(path); err == nil {
+		return digest, nil
+	}
+
+	// Write to a temporary file and rename it to never leave partially
+	// written documents under a digest name
+	tmp, err := os.CreateTemp(dir, ".vexctl-cas-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("writing document: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("closing document: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), os.FileMode(0o644)); err != nil {
+		return "", fmt.Errorf("setting document permissions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("storing document: %w", err)
+	}
+	return digest, nil
+}
+
+// LoadCAS reads a document from a content addressable store written by
+// StoreCAS. The contents of the file are verified against the digest.
+func LoadCAS(dir, digest string) (*vex.VEX, error) {
+	if !digestRegexp.MatchString(digest) {
+		return nil, fmt.Errorf("invalid document digest %q", digest)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, digest+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("reading document: %w", err)
+	}
+
+	if digestBytes(data) != digest {
+		return nil, errors.New("document contents do not match its digest")
+	}
+
+	return vex.Parse(data)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestCAS(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(ts, testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts))
+	dir := t.TempDir()
+
+	digest, err := StoreCAS(doc, dir)
+	require.NoError(t, err)
+	require.Len(t, digest, 64)
+
+	expected, err := Digest(doc)
+	require.NoError(t, err)
+	require.Equal(t, expected, digest)
+
+	// Storing the same document again is a no-op
+	digest2, err := StoreCAS(doc, dir)
+	require.NoError(t, err)
+	require.Equal(t, digest, digest2)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, digest+".json", entries[0].Name())
+
+	loaded, err := LoadCAS(dir, digest)
+	require.NoError(t, err)
+	require.Equal(t, doc.Statements[0].Status, loaded.Statements[0].Status)
+	loadedDigest, err := Digest(loaded)
+	require.NoError(t, err)
+	require.Equal(t, digest, loadedDigest)
+
+	// Changing the document changes its digest
+	doc.Version++
+	digest3, err := StoreCAS(doc, dir)
+	require.NoError(t, err)
+	require.NotEqual(t, digest, digest3)
+
+	// Tampered files and invalid digests are rejected
+	require.NoError(t, os.WriteFile(filepath.Join(dir, digest+".json"), []byte("{}"), os.FileMode(0o644)))
+	_, err = LoadCAS(dir, digest)
+	require.Error(t, err)
+	_, err = LoadCAS(dir, "../"+digest)
+	require.Error(t, err)
+}
+
+func TestStatementDigest(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)
+	s.Vulnerability.Aliases = []vex.VulnerabilityID{"GHSA-aaaa-bbbb-cccc", "CVE-2023-0001"}
+	s.Products = append(s.Products, vex.Product{Component: vex.Component{ID: "pkg:apk/wolfi/bash@2.0.0"}})
+
+	digest := StatementDigest(s)
+	require.Len(t, digest, 64)
+	require.True(t, digestRegexp.MatchString(digest))
+
+	// The order of products and aliases and the time zone don't matter
+	same := s
+	same.Vulnerability.Aliases = []vex.VulnerabilityID{"CVE-2023-0001", "GHSA-aaaa-bbbb-cccc"}
+	same.Products = []vex.Product{s.Products[1], s.Products[0]}
+	local := ts.In(time.FixedZone("CET", 3600))
+	same.Timestamp = &local
+	require.Equal(t, digest, StatementDigest(same))
+
+	// The statement passed is not modified
+	require.Equal(t, "pkg:apk/wolfi/bash@1.0.0", s.Products[0].ID)
+	require.Equal(t, vex.VulnerabilityID("GHSA-aaaa-bbbb-cccc"), s.Vulnerability.Aliases[0])
+
+	// Products are sorted by purl when they have no ID, and by their contents
+	// when they have neither
+	purlOnly := vex.Product{Component: vex.Component{
+		Identifiers: map[vex.IdentifierType]string{vex.PURL: "pkg:apk/wolfi/curl@1.0.0"},
+	}}
+	a := vex.Product{Component: vex.Component{Hashes: map[vex.Algorithm]vex.Hash{vex.SHA256: "aaaa"}}}
+	b := vex.Product{Component: vex.Component{Hashes: map[vex.Algorithm]vex.Hash{vex.SHA256: "bbbb"}}}
+	withSubs := testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)
+	withSubs.Products = []vex.Product{purlOnly, s.Products[0], a, b}
+	withSubs.Products[1].Subcomponents = []vex.Subcomponent{{Component: b.Component}, {Component: purlOnly.Component}, {Component: a.Component}}
+	reordered := withSubs
+	reordered.Products = []vex.Product{b, s.Products[0], a, purlOnly}
+	reordered.Products[1].Subcomponents = []vex.Subcomponent{{Component: a.Component}, {Component: b.Component}, {Component: purlOnly.Component}}
+	require.Equal(t, StatementDigest(withSubs), StatementDigest(reordered))
+
+	different := s
+	different.Status = vex.StatusAffected
+	require.NotEqual(t, digest, StatementDigest(different))
+}
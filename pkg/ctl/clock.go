@@ -0,0 +1,33 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// TimeSource returns the current time. vexctl uses it every time it dates a
+// document or statement, so tests and reproducible builds can override it
+// to get documents with fixed timestamps.
+var TimeSource = time.Now
+
+// NewDocument returns a new empty document, like vex.New, authored by
+// DefaultAuthor and DefaultAuthorRole and dated with the time returned by
+// TimeSource. As with vex.New, a date set in the SOURCE_DATE_EPOCH
+// environment variable takes precedence.
+func NewDocument() vex.VEX {
+	doc := vex.New()
+	doc.Author = DefaultAuthor
+	doc.AuthorRole = DefaultAuthorRole
+	if t, err := vex.DateFromEnv(); err == nil && t != nil {
+		return doc
+	}
+	now := TimeSource()
+	doc.Timestamp = &now
+	return doc
+}
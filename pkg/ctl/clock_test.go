@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestTimeSource(t *testing.T) {
+	fixed := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	defer func(original func() time.Time) { TimeSource = original }(TimeSource)
+	TimeSource = func() time.Time { return fixed }
+
+	doc := NewDocument()
+	require.Equal(t, fixed, *doc.Timestamp)
+
+	built, err := NewBuilder().Fixed("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0").Build()
+	require.NoError(t, err)
+	require.Equal(t, fixed, *built.Timestamp)
+
+	n, err := UpdateStatement(built, func(vex.Statement) bool { return true }, func(s *vex.Statement) error {
+		s.StatusNotes = "Fixed upstream"
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, fixed, *built.LastUpdated)
+	require.Equal(t, fixed, *built.Statements[0].LastUpdated)
+
+	// A date in SOURCE_DATE_EPOCH takes precedence
+	t.Setenv("SOURCE_DATE_EPOCH", "1672531200")
+	doc = NewDocument()
+	require.True(t, time.Unix(1672531200, 0).Equal(*doc.Timestamp))
+}
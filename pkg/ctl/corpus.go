@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// StatusChange records how the effective status of a vulnerability changed
+// between two corpora. From is empty when the vulnerability is new and To is
+// empty when the new corpus has no statements about it.
+type StatusChange struct {
+	Vulnerability string     `json:"vulnerability"`
+	From          vex.Status `json:"from,omitempty"`
+	To            vex.Status `json:"to,omitempty"`
+}
+
+// CorpusDiff captures the changes in the effective statuses of the
+// vulnerabilities of a product between two corpora
+type CorpusDiff struct {
+	Product string         `json:"product"`
+	Changes []StatusChange `json:"changes"`
+}
+
+// DiffCorpora compares the effective statuses of the vulnerabilities of a
+// product in two sets of documents, for example the documents published
+// yesterday and today, and returns the vulnerabilities whose status changed
+// sorted by name. Vulnerabilities with the same status in both corpora are
+// not included.
+func DiffCorpora(oldDocs, newDocs []*vex.VEX, productID string) *CorpusDiff {
+	before := EffectiveStatuses(oldDocs, productID)
+	after := EffectiveStatuses(newDocs, productID)
+
+	diff := &CorpusDiff{Product: productID, Changes: []StatusChange{}}
+	for vuln, from := range before {
+		if to := after[vuln]; to != from {
+			diff.Changes = append(diff.Changes, StatusChange{Vulnerability: vuln, From: from, To: to})
+		}
+	}
+	for vuln, to := range after {
+		if _, ok := before[vuln]; !ok {
+			diff.Changes = append(diff.Changes, StatusChange{Vulnerability: vuln, To: to})
+		}
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Vulnerability < diff.Changes[j].Vulnerability
+	})
+	return diff
+}
+
+// ConsolidateByProduct merges a corpus down to one document per product,
+// keyed by product ID. Each document has the effective statement about each
+// vulnerability of the product across the corpus (see EffectiveStatuses),
+// with its products reduced to the one the document is about. The documents
+// get a deterministic ID derived from the product and are dated with their
+// latest statement, so consolidating the same corpus always produces the
+// same documents. The exception are products whose statements and documents
+// have no timestamps at all: those documents are dated with TimeSource, which
+// callers needing reproducible output must pin.
+func ConsolidateByProduct(docs []*vex.VEX) map[string]*vex.VEX {
+	products := []string{}
+	seen := map[string]struct{}{}
+	for _, doc := range docs {
+		for _, p := range documentProducts(doc) {
+			if _, ok := seen[p]; ok {
+				continue
+			}
+			seen[p] = struct{}{}
+			products = append(products, p)
+		}
+	}
+
+	consolidated := make(map[string]*vex.VEX, len(products))
+	for _, productID := range products {
+		newDoc := NewDocument()
+		newDoc.ID = fmt.Sprintf("consolidated-vex-%x", sha256.Sum256([]byte(productID)))
+		if len(docs) > 0 {
+			newDoc.Supplier = commonSupplier(docs)
+		}
+
+		var latest time.Time
+		for _, s := range effectiveStatements(docs, productID) { //nolint:gocritic // this IS supposed to copy
+			s.Products = productsMatching(s.Products, productID)
+			if s.Timestamp != nil && s.Timestamp.After(latest) {
+				latest = *s.Timestamp
+			}
+			newDoc.Statements = append(newDoc.Statements, s)
+		}
+		if !latest.IsZero() {
+			newDoc.Timestamp = &latest
+		}
+		vex.SortStatements(newDoc.Statements, *newDoc.Timestamp)
+		consolidated[productID] = &newDoc
+	}
+	return consolidated
+}
+
+// productsMatching returns the products in a list matching an identifier
+func productsMatching(products []vex.Product, identifier string) []vex.Product {
+	matching := []vex.Product{}
+	for i := range products {
+		if productMatchesWith(&products[i], identifier, DefaultProductMatcher) {
+			matching = append(matching, products[i])
+		}
+	}
+	return matching
+}
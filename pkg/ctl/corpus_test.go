@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestDiffCorpora(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+
+	yesterday := []*vex.VEX{
+		testDocument(t1,
+			testStatement("CVE-2023-0001", product, vex.StatusNotAffected, t1),
+			testStatement("CVE-2023-0002", product, vex.StatusFixed, t1),
+			testStatement("CVE-2023-0003", product, vex.StatusUnderInvestigation, t1),
+		),
+	}
+	today := []*vex.VEX{
+		yesterday[0],
+		testDocument(t2,
+			testStatement("CVE-2023-0001", product, vex.StatusAffected, t2),
+			testStatement("CVE-2023-0002", product, vex.StatusFixed, t2),
+			testStatement("CVE-2023-0004", product, vex.StatusUnderInvestigation, t2),
+		),
+	}
+
+	require.Equal(t, &CorpusDiff{
+		Product: product,
+		Changes: []StatusChange{
+			{Vulnerability: "CVE-2023-0001", From: vex.StatusNotAffected, To: vex.StatusAffected},
+			{Vulnerability: "CVE-2023-0004", To: vex.StatusUnderInvestigation},
+		},
+	}, DiffCorpora(yesterday, today, product))
+
+	// Vulnerabilities no longer covered are reported too
+	diff := DiffCorpora(today, yesterday[:0], product)
+	require.Len(t, diff.Changes, 4)
+	require.Equal(t, StatusChange{Vulnerability: "CVE-2023-0001", From: vex.StatusAffected}, diff.Changes[0])
+
+	require.Empty(t, DiffCorpora(today, today, product).Changes)
+}
+
+func TestConsolidateByProduct(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@1.0.0"
+
+	both := testStatement("CVE-2023-0001", bash, vex.StatusAffected, t1)
+	both.Products = append(both.Products, vex.Product{Component: vex.Component{ID: git}})
+	docs := []*vex.VEX{
+		testDocument(t2,
+			testStatement("CVE-2023-0001", bash, vex.StatusFixed, t2),
+			testStatement("CVE-2023-0002", git, vex.StatusUnderInvestigation, t2),
+		),
+		testDocument(t1, both),
+	}
+
+	consolidated := ConsolidateByProduct(docs)
+	require.Len(t, consolidated, 2)
+
+	// The later fix takes precedence over the affected status in bash
+	bashDoc := consolidated[bash]
+	require.Len(t, bashDoc.Statements, 1)
+	require.Equal(t, vex.StatusFixed, bashDoc.Statements[0].Status)
+	require.Equal(t, t2, *bashDoc.Timestamp)
+
+	// git is still affected, and the statement only lists git
+	gitDoc := consolidated[git]
+	require.Len(t, gitDoc.Statements, 2)
+	require.Equal(t, vex.StatusAffected, gitDoc.Statements[0].Status)
+	require.Len(t, gitDoc.Statements[0].Products, 1)
+	require.Equal(t, git, gitDoc.Statements[0].Products[0].ID)
+	require.Equal(t, vex.StatusUnderInvestigation, gitDoc.Statements[1].Status)
+	require.NotEqual(t, bashDoc.ID, gitDoc.ID)
+
+	// The source statements are not modified
+	require.Len(t, docs[1].Statements[0].Products, 2)
+
+	// Consolidation is deterministic
+	require.Equal(t, consolidated, ConsolidateByProduct(docs))
+
+	// Undated corpora are dated with the time source
+	now := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	defer func(original func() time.Time) { TimeSource = original }(TimeSource)
+	TimeSource = func() time.Time { return now }
+	undated := testDocument(t1, testStatement("CVE-2023-0001", bash, vex.StatusFixed, t1))
+	undated.Timestamp = nil
+	undated.Statements[0].Timestamp = nil
+	require.Equal(t, now, *ConsolidateByProduct([]*vex.VEX{undated})[bash].Timestamp)
+	require.Equal(t, ConsolidateByProduct([]*vex.VEX{undated}), ConsolidateByProduct([]*vex.VEX{undated}))
+}
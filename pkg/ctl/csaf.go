@@ -0,0 +1,469 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/csaf"
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// CSAFOptions control how CSAF documents are imported
+type CSAFOptions struct {
+	// Products limits the import to the statements about the products
+	// matching these IDs or identifiers. All products are imported when
+	// the list is empty.
+	Products []string
+
+	// Lenient makes the import skip the product statuses that cannot be
+	// translated to VEX instead of failing on the first one.
+	Lenient bool
+
+	// Provenance, when set, gets the CSAF document recorded as an input,
+	// identified by its path and the sha256 digest of its contents.
+	Provenance *Provenance
+
+	// ResolveAuthor, when set, derives the author and role of the
+	// document from its source, which is passed as a *CSAFSource. Empty
+	// values, as well as the author and role of documents imported without
+	// a resolver, are set to DefaultAuthor and DefaultAuthorRole.
+	ResolveAuthor AuthorResolver
+
+	// Warn, when set, is called with the recoverable problems found
+	// during the import, such as the entries skipped in lenient mode.
+	Warn func(Warning)
+}
+
+// CSAFEntryError captures a CSAF product status that could not be imported
+type CSAFEntryError struct {
+	Vulnerability string // CVE of the CSAF vulnerability
+	ProductID     string // ID of the product in the CSAF product tree
+	Status        string // The CSAF product status
+	Err           error
+}
+
+func (ce *CSAFEntryError) Error() string {
+	return fmt.Sprintf("%s on %s (%s): %s", ce.Vulnerability, ce.ProductID, ce.Status, ce.Err)
+}
+
+func (ce *CSAFEntryError) Unwrap() error {
+	return ce.Err
+}
+
+// OpenCSAF opens a CSAF document and builds a VEX document from it. When a
+// list of products is specified, only statements about the products matching
+// those IDs or identifiers are included. If none of the listed products is
+// found in the CSAF document, an error naming them is returned.
+//
+// OpenCSAF builds a statement for the same vulnerabilities, statuses and
+// products as go-vex's vex.OpenCSAF, but the rest of the document differs:
+// the OpenVEX context, default author and CSAF publisher as supplier are
+// recorded, product hashes and the products defined by relationships are
+// read, flags become justifications and the threat details are only used as
+// the action or impact statement of the statuses that take them. OpenCSAF is
+// also designed to handle large documents, such as those found in aggregated
+// feeds, building its product lookups only once.
+func OpenCSAF(path string, products []string) (*vex.VEX, error) {
+	doc, _, err := OpenCSAFWithOptions(path, &CSAFOptions{Products: products})
+	return doc, err
+}
+
+// OpenCSAFWithOptions opens a CSAF document and builds a VEX document from
+// it using the specified options. By default, the import fails if any of the
+// product statuses cannot be imported. In lenient mode, those entries are
+// skipped and returned as warnings along with the document built from the
+// rest of the data. Nil options use the defaults.
+func OpenCSAFWithOptions(path string, opts *CSAFOptions) (*vex.VEX, []*CSAFEntryError, error) {
+	if opts == nil {
+		opts = &CSAFOptions{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening csaf doc: %w", err)
+	}
+	if opts.Provenance != nil {
+		opts.Provenance.AddInput(path, digestBytes(data))
+	}
+	return parseCSAF(data, opts)
+}
+
+// AuthorResolver derives the author and role of an imported document from
+// its source data
+type AuthorResolver func(src any) (author, role string)
+
+// CSAFSource is the source data an AuthorResolver gets for CSAF imports
+type CSAFSource struct {
+	Document  *csaf.CSAF
+	Category  string // The category of the CSAF document, eg csaf_vex
+	Publisher CSAFPublisher
+}
+
+// CSAFCategoryVEX is the category of the CSAF documents following the VEX
+// profile
+const CSAFCategoryVEX = "csaf_vex"
+
+// CSAFPublisher is the publisher of a CSAF document, which is not part of
+// the go-vex CSAF types
+type CSAFPublisher struct {
+	Category  string `json:"category"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// csafDocumentInfo captures the CSAF document category and publisher
+type csafDocumentInfo struct {
+	Document struct {
+		Category  string        `json:"category"`
+		Publisher CSAFPublisher `json:"publisher"`
+	} `json:"document"`
+}
+
+// csafHashAlgorithms maps the CSAF hash algorithm names, as listed by
+// openssl, to the OpenVEX ones
+var csafHashAlgorithms = map[string]vex.Algorithm{
+	"md5":      vex.MD5,
+	"sha1":     vex.SHA1,
+	"sha256":   vex.SHA256,
+	"sha384":   vex.SHA384,
+	"sha512":   vex.SHA512,
+	"sha3-224": vex.SHA3224,
+	"sha3-256": vex.SHA3256,
+	"sha3-384": vex.SHA3384,
+	"sha3-512": vex.SHA3512,
+}
+
+// csafHashes captures the hashes in a product identification helper
+type csafHashes []struct {
+	FileHashes []struct {
+		Algorithm string `json:"algorithm"`
+		Value     string `json:"value"`
+	} `json:"file_hashes"`
+}
+
+// extractCSAFHashes returns the file hashes of the products in a CSAF
+// document, indexed by product ID, and the document data without them. The
+// go-vex CSAF types expect all identification helpers to be strings, so the
+// hashes need to be removed for them to decode the document.
+func extractCSAFHashes(data []byte) ([]byte, map[string]map[vex.Algorithm]vex.Hash, error) {
+	hashes := map[string]map[vex.Algorithm]vex.Hash{}
+	if !bytes.Contains(data, []byte(`"hashes"`)) {
+		return data, hashes, nil
+	}
+
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("csaf: failed to decode document: %w", err)
+	}
+
+	var walkErr error
+	var walk func(any)
+	walk = func(v any) {
+		switch node := v.(type) {
+		case []any:
+			for _, e := range node {
+				walk(e)
+			}
+		case map[string]any:
+			if helper, ok := node["product_identification_helper"].(map[string]any); ok {
+				if h, ok := helper["hashes"]; ok {
+					delete(helper, "hashes")
+					id, _ := node["product_id"].(string)
+					if err := addCSAFHashes(hashes, id, h); err != nil {
+						walkErr = err
+					}
+				}
+			}
+			for _, e := range node {
+				walk(e)
+			}
+		}
+	}
+	walk(raw)
+	if walkErr != nil {
+		return nil, nil, walkErr
+	}
+
+	stripped, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("csaf: re-encoding document: %w", err)
+	}
+	return stripped, hashes, nil
+}
+
+// addCSAFHashes decodes the hashes of a product and adds the ones with known
+// algorithms to the index
+func addCSAFHashes(index map[string]map[vex.Algorithm]vex.Hash, productID string, h any) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("csaf: encoding hashes of %s: %w", productID, err)
+	}
+	list := csafHashes{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("csaf: failed to decode hashes of %s: %w", productID, err)
+	}
+	for _, fh := range list {
+		for _, entry := range fh.FileHashes {
+			algo, ok := csafHashAlgorithms[strings.ToLower(entry.Algorithm)]
+			if !ok || entry.Value == "" {
+				continue
+			}
+			if index[productID] == nil {
+				index[productID] = map[vex.Algorithm]vex.Hash{}
+			}
+			index[productID][algo] = vex.Hash(strings.ToLower(entry.Value))
+		}
+	}
+	return nil
+}
+
+// parseCSAF parses CSAF data and builds a VEX document from it. The
+// publisher of the CSAF document is recorded as the supplier, the file
+// hashes of the products are recorded in their VEX products and the author
+// is set with the resolver in the options, if any.
+func parseCSAF(data []byte, opts *CSAFOptions) (*vex.VEX, []*CSAFEntryError, error) {
+	if opts == nil {
+		opts = &CSAFOptions{}
+	}
+	data, hashes, err := extractCSAFHashes(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	csafDoc := &csaf.CSAF{}
+	if err := json.Unmarshal(data, csafDoc); err != nil {
+		return nil, nil, fmt.Errorf("csaf: failed to decode document: %w", err)
+	}
+
+	info := &csafDocumentInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, nil, fmt.Errorf("csaf: failed to decode document category and publisher: %w", err)
+	}
+	if category := info.Document.Category; category != CSAFCategoryVEX {
+		warnFunc(opts.Warn)(WarningCSAFCategory, fmt.Sprintf(
+			"csaf: document %s has category %q, not %s", csafDoc.Document.Tracking.ID, category, CSAFCategoryVEX,
+		))
+	}
+
+	doc, skipped, err := vexFromCSAF(csafDoc, hashes, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc.Supplier = info.Document.Publisher.Name
+	if opts.ResolveAuthor != nil {
+		doc.Author, doc.AuthorRole = opts.ResolveAuthor(&CSAFSource{
+			Document: csafDoc, Category: info.Document.Category, Publisher: info.Document.Publisher,
+		})
+	}
+	FillDefaults(doc)
+	return doc, skipped, nil
+}
+
+// vexFromCSAF builds a VEX document from a parsed CSAF document and the
+// hashes of its products
+func vexFromCSAF(
+	csafDoc *csaf.CSAF, hashes map[string]map[vex.Algorithm]vex.Hash, opts *CSAFOptions,
+) (*vex.VEX, []*CSAFEntryError, error) {
+	productSet := resolveProducts(csafDoc, hashes, opts.Products)
+	if len(opts.Products) > 0 && len(productSet) == 0 {
+		return nil, nil, fmt.Errorf(
+			"csaf: none of the products were found in the document: %s",
+			strings.Join(opts.Products, ", "),
+		)
+	}
+
+	v := &vex.VEX{
+		Metadata: vex.Metadata{
+			Context:   vex.ContextLocator(),
+			ID:        csafDoc.Document.Tracking.ID,
+			Timestamp: &time.Time{},
+		},
+		Statements: []vex.Statement{},
+	}
+
+	warn := warnFunc(opts.Warn)
+	warn(WarningZeroTimestamp, fmt.Sprintf(
+		"csaf: document %s is imported without a timestamp", csafDoc.Document.Tracking.ID,
+	))
+
+	skipped := []*CSAFEntryError{}
+	for i := range csafDoc.Vulnerabilities {
+		vuln := &csafDoc.Vulnerabilities[i]
+		justifications := extractJustification(vuln)
+		flags := extractFlags(vuln)
+
+		// Range the statuses in order to produce a stable document
+		statuses := make([]string, 0, len(vuln.ProductStatus))
+		for status := range vuln.ProductStatus {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+
+		for _, csafStatus := range statuses {
+			for _, productID := range vuln.ProductStatus[csafStatus] {
+				if _, ok := productSet[productID]; !ok {
+					continue
+				}
+
+				status, err := extractStatus(csafStatus, productID)
+				if err != nil {
+					if !opts.Lenient {
+						return nil, nil, err
+					}
+					entryErr := &CSAFEntryError{
+						Vulnerability: vuln.CVE, ProductID: productID, Status: csafStatus, Err: err,
+					}
+					skipped = append(skipped, entryErr)
+					warn(WarningSkippedStatus, fmt.Sprintf("csaf: skipped %s", entryErr))
+					continue
+				}
+
+				s := vex.Statement{
+					Vulnerability: vex.Vulnerability{Name: vex.VulnerabilityID(vuln.CVE)},
+					Status:        status,
+					Products: []vex.Product{
+						{Component: vex.Component{ID: productID, Hashes: hashes[productID]}},
+					},
+				}
+				// The threat details are only meaningful as the action of
+				// affected products or the impact of not_affected ones,
+				// any other status must leave both statements empty.
+				switch status {
+				case vex.StatusAffected:
+					s.ActionStatement = justifications[productID]
+				case vex.StatusNotAffected:
+					s.Justification = flags[productID]
+					s.ImpactStatement = justifications[productID]
+				}
+				v.Statements = append(v.Statements, s)
+			}
+		}
+	}
+
+	return v, skipped, nil
+}
+
+// resolveProducts returns the set of product IDs from the CSAF product tree
+// that statements can be built for. If a products filter is specified, only
+// products whose ID or identification helpers are listed are returned.
+func resolveProducts(
+	csafDoc *csaf.CSAF, hashes map[string]map[vex.Algorithm]vex.Hash, products []string,
+) map[string]struct{} {
+	filter := make(map[string]struct{}, len(products))
+	for _, p := range products {
+		filter[p] = struct{}{}
+	}
+
+	set := map[string]struct{}{}
+	for _, sp := range listCSAFProducts(csafDoc, hashes) {
+		// Only products with identification helpers (or hashes) can
+		// be captured in VEX statements.
+		if len(sp.IdentificationHelper) == 0 && len(hashes[sp.ID]) == 0 {
+			continue
+		}
+
+		if len(filter) > 0 && !productInFilter(&sp, filter) {
+			continue
+		}
+
+		set[sp.ID] = struct{}{}
+	}
+	return set
+}
+
+// listCSAFProducts returns the products in the branches of the CSAF product
+// tree followed by those defined by its relationships. Vendors like Red Hat
+// only list identification helpers in the components and then combine them
+// with the platforms they ship in, referencing the composite product IDs
+// (eg red_hat_enterprise_linux_9:kernel-0:5.14.0-284.el9) in the
+// vulnerabilities. Relationship products without helpers or hashes inherit
+// them from the component they reference, the hashes are added to the index.
+func listCSAFProducts(csafDoc *csaf.CSAF, hashes map[string]map[vex.Algorithm]vex.Hash) csaf.ProductList {
+	products := csafDoc.ProductTree.ListProducts()
+	components := make(map[string]csaf.Product, len(products))
+	for _, p := range products {
+		components[p.ID] = p
+	}
+
+	for _, r := range csafDoc.ProductTree.Relationships {
+		p := r.FullProductName
+		if _, ok := components[p.ID]; ok || p.ID == "" {
+			continue
+		}
+		if len(p.IdentificationHelper) == 0 {
+			p.IdentificationHelper = components[r.ProductRef].IdentificationHelper
+		}
+		if _, ok := hashes[p.ID]; !ok && len(hashes[r.ProductRef]) > 0 {
+			hashes[p.ID] = hashes[r.ProductRef]
+		}
+		// ProductList.Add drops products sharing helpers, so the
+		// composite products are appended directly
+		components[p.ID] = p
+		products = append(products, p)
+	}
+	return products
+}
+
+// productInFilter returns true if the product ID or any of its identification
+// helpers are in the filter set.
+func productInFilter(p *csaf.Product, filter map[string]struct{}) bool {
+	if _, ok := filter[p.ID]; ok {
+		return true
+	}
+	for _, h := range p.IdentificationHelper {
+		if _, ok := filter[h]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extractStatus translates a CSAF product status into its VEX status
+func extractStatus(csafStatus, productID string) (vex.Status, error) {
+	status := vex.StatusFromCSAF(csafStatus)
+	if status == "" {
+		return "", fmt.Errorf("invalid status for product %s", productID)
+	}
+	return status, nil
+}
+
+// extractJustification indexes the threat details of a vulnerability by
+// product ID. CSAF justifications are not machine readable so the details
+// are used as free form text. When several threats list the same product,
+// the last one wins.
+func extractJustification(vuln *csaf.Vulnerability) map[string]string {
+	justifications := map[string]string{}
+	for _, t := range vuln.Threats {
+		for _, p := range t.ProductIDs {
+			justifications[p] = t.Details
+		}
+	}
+	return justifications
+}
+
+// extractFlags indexes the machine readable justifications of a vulnerability
+// by product ID. The CSAF flag labels are the same as the VEX justifications,
+// flags with unknown labels are ignored. Flags referencing product groups are
+// not resolved as vexctl does not read the groups of the product tree.
+func extractFlags(vuln *csaf.Vulnerability) map[string]vex.Justification {
+	flags := map[string]vex.Justification{}
+	for _, f := range vuln.Flags {
+		j := vex.Justification(f.Label)
+		if !j.Valid() {
+			continue
+		}
+		for _, p := range f.ProductIDs {
+			flags[p] = j
+		}
+	}
+	return flags
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/csaf"
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// writeTestCSAF writes a CSAF document to path resembling the aggregated
+// advisories found in large feeds: every vulnerability lists the status of
+// every product and a threat for each one of them.
+func writeTestCSAF(t testing.TB, path string, vulns, products int) {
+	doc := csaf.CSAF{
+		Document: csaf.DocumentMetadata{
+			Title:    "Aggregated advisory",
+			Tracking: csaf.Tracking{ID: "AGGREGATE-0001"},
+		},
+	}
+
+	ids := make([]string, products)
+	for p := 0; p < products; p++ {
+		ids[p] = fmt.Sprintf("PRODUCT-%04d", p)
+		doc.ProductTree.Branches = append(doc.ProductTree.Branches, csaf.ProductBranch{
+			Category: "product_version",
+			Name:     ids[p],
+			Product: csaf.Product{
+				Name: ids[p],
+				ID:   ids[p],
+				IdentificationHelper: map[string]string{
+					"purl": fmt.Sprintf("pkg:apk/wolfi/package-%04d@1.0.0", p),
+				},
+			},
+		})
+	}
+
+	for v := 0; v < vulns; v++ {
+		vuln := csaf.Vulnerability{
+			CVE: fmt.Sprintf("CVE-2023-%04d", v),
+			ProductStatus: map[string][]string{
+				"fixed":              ids[:products/2],
+				"known_not_affected": ids[products/2:],
+			},
+		}
+		for _, id := range ids {
+			vuln.Threats = append(vuln.Threats, csaf.ThreatData{
+				Category:   "impact",
+				Details:    fmt.Sprintf("%s is not affected by CVE-2023-%04d", id, v),
+				ProductIDs: []string{id},
+			})
+		}
+		doc.Vulnerabilities = append(doc.Vulnerabilities, vuln)
+	}
+
+	data, err := json.Marshal(&doc)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, os.FileMode(0o644)))
+}
+
+// TestOpenCSAFGolden checks that OpenCSAF produces statements about the same
+// vulnerabilities, statuses and products as the original go-vex
+// implementation. The fields OpenCSAF fills differently are cleared before
+// comparing.
+func TestOpenCSAFGolden(t *testing.T) {
+	aggregate := filepath.Join(t.TempDir(), "aggregate.csaf.json")
+	writeTestCSAF(t, aggregate, 20, 30)
+
+	for _, tc := range []struct {
+		name       string
+		path       string
+		products   []string
+		statements int
+	}{
+		{"small", "testdata/csaf/csaf.json", []string{}, 1},
+		{"small filtered by id", "testdata/csaf/csaf.json", []string{"CSAFPID-0001"}, 1},
+		{"small filtered by purl", "testdata/csaf/csaf.json", []string{"pkg:maven/@1.3.4"}, 1},
+		{"aggregate", aggregate, []string{}, 600},
+		{"aggregate filtered", aggregate, []string{"PRODUCT-0001", "pkg:apk/wolfi/package-0002@1.0.0"}, 40},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			golden, err := vex.OpenCSAF(tc.path, tc.products)
+			require.NoError(t, err)
+
+			doc, err := OpenCSAF(tc.path, tc.products)
+			require.NoError(t, err)
+
+			// go-vex neither reads the CSAF publisher nor sets the
+			// context and default author
+			metadata := doc.Metadata
+			metadata.Supplier = ""
+			require.Equal(t, vex.ContextLocator(), metadata.Context)
+			metadata.Context = ""
+			require.Equal(t, DefaultAuthor, metadata.Author)
+			metadata.Author = ""
+			require.Equal(t, golden.Metadata, metadata)
+			require.Len(t, doc.Statements, tc.statements)
+			// go-vex ranges the product statuses map, so its
+			// statement order is not stable. It also copies the threat
+			// details to the action statement of every status, while
+			// OpenCSAF only uses them where the status takes them.
+			require.ElementsMatch(t, withoutStatements(golden.Statements), withoutStatements(doc.Statements))
+		})
+	}
+
+	_, err := OpenCSAF("testdata/csaf/missing.json", nil)
+	require.Error(t, err)
+}
+
+// withoutStatements returns a copy of a list of statements with their impact
+// and action statements cleared
+func withoutStatements(statements []vex.Statement) []vex.Statement {
+	cleared := make([]vex.Statement, len(statements))
+	for i, s := range statements { //nolint:gocritic // this IS supposed to copy
+		s.ImpactStatement, s.ActionStatement = "", ""
+		cleared[i] = s
+	}
+	return cleared
+}
+
+func TestOpenCSAFUnmatchedProducts(t *testing.T) {
+	_, err := OpenCSAF("testdata/csaf/csaf.json", []string{"pkg:maven/nothing@1.0.0", "CSAFPID-9999"})
+	require.ErrorContains(t, err, "pkg:maven/nothing@1.0.0, CSAFPID-9999")
+
+	// Filters matching some product keep working
+	doc, err := OpenCSAF("testdata/csaf/csaf.json", []string{"pkg:maven/nothing@1.0.0", "CSAFPID-0001"})
+	require.NoError(t, err)
+	require.Len(t, doc.Statements, 1)
+}
+
+func TestParseDocumentCSAF(t *testing.T) {
+	data, err := os.ReadFile("testdata/csaf/csaf.json")
+	require.NoError(t, err)
+	doc, err := ParseDocument(data)
+	require.NoError(t, err)
+
+	expected, err := OpenCSAF("testdata/csaf/csaf.json", nil)
+	require.NoError(t, err)
+	require.Equal(t, expected, doc)
+}
+
+func BenchmarkOpenCSAF(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "aggregate.csaf.json")
+	writeTestCSAF(b, path, 100, 500)
+
+	for _, bc := range []struct {
+		name string
+		open func(string, []string) (*vex.VEX, error)
+	}{
+		{"go-vex", vex.OpenCSAF},
+		{"vexctl", OpenCSAF},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.open(path, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenCSAFLenient(t *testing.T) {
+	path := "testdata/csaf/csaf-invalid-status.json"
+
+	// Strict mode is the default
+	_, err := OpenCSAF(path, nil)
+	require.Error(t, err)
+	_, _, err = OpenCSAFWithOptions(path, &CSAFOptions{})
+	require.Error(t, err)
+	_, _, err = OpenCSAFWithOptions(path, nil)
+	require.Error(t, err)
+
+	doc, skipped, err := OpenCSAFWithOptions(path, &CSAFOptions{Lenient: true})
+	require.NoError(t, err)
+	require.Len(t, doc.Statements, 2)
+	require.Equal(t, "CVE-2009-4487", string(doc.Statements[0].Vulnerability.Name))
+	require.Equal(t, "CVE-2023-0001", string(doc.Statements[1].Vulnerability.Name))
+	require.Equal(t, vex.StatusFixed, doc.Statements[1].Status)
+
+	require.Len(t, skipped, 1)
+	require.Equal(t, "CVE-2023-0001", skipped[0].Vulnerability)
+	require.Equal(t, "CSAFPID-0001", skipped[0].ProductID)
+	require.Equal(t, "cheese", skipped[0].Status)
+}
+
+func TestOpenCSAFWarnings(t *testing.T) {
+	warnings := []Warning{}
+	_, _, err := OpenCSAFWithOptions("testdata/csaf/csaf-invalid-status.json", &CSAFOptions{
+		Lenient: true,
+		Warn:    func(w Warning) { warnings = append(warnings, w) },
+	})
+	require.NoError(t, err)
+	require.Len(t, warnings, 2)
+	require.Equal(t, WarningZeroTimestamp, warnings[0].Kind)
+	require.Equal(t, WarningSkippedStatus, warnings[1].Kind)
+	require.Contains(t, warnings[1].Message, "CVE-2023-0001 on CSAFPID-0001 (cheese)")
+
+	// Documents not following the VEX profile are flagged
+	data, err := os.ReadFile("testdata/csaf/csaf.json")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "advisory.json")
+	require.NoError(t, os.WriteFile(path, bytes.Replace(
+		data, []byte(`"csaf_vex"`), []byte(`"csaf_security_advisory"`), 1,
+	), os.FileMode(0o644)))
+
+	warnings = []Warning{}
+	category := ""
+	_, _, err = OpenCSAFWithOptions(path, &CSAFOptions{
+		Warn: func(w Warning) { warnings = append(warnings, w) },
+		ResolveAuthor: func(src any) (string, string) {
+			category = src.(*CSAFSource).Category
+			return "", ""
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "csaf_security_advisory", category)
+	require.Len(t, warnings, 2)
+	require.Equal(t, WarningCSAFCategory, warnings[0].Kind)
+	require.Contains(t, warnings[0].Message, "csaf_security_advisory")
+}
+
+func TestOpenCSAFAuthorResolver(t *testing.T) {
+	doc, _, err := OpenCSAFWithOptions("testdata/csaf/csaf.json", &CSAFOptions{
+		ResolveAuthor: func(src any) (string, string) {
+			csafSrc, ok := src.(*CSAFSource)
+			require.True(t, ok)
+			return csafSrc.Publisher.Name + " PSIRT", csafSrc.Publisher.Category
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Example Company PSIRT", doc.Author)
+	require.Equal(t, "vendor", doc.AuthorRole)
+
+	// Empty values get the defaults
+	doc, _, err = OpenCSAFWithOptions("testdata/csaf/csaf.json", &CSAFOptions{
+		ResolveAuthor: func(any) (string, string) { return "Jane Doe", "" },
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Jane Doe", doc.Author)
+	require.Equal(t, DefaultAuthorRole, doc.AuthorRole)
+}
+
+func TestOpenCSAFSupplier(t *testing.T) {
+	doc, err := OpenCSAF("testdata/csaf/csaf.json", nil)
+	require.NoError(t, err)
+	require.Equal(t, "Example Company", doc.Supplier)
+	require.Equal(t, DefaultAuthor, doc.Author)
+	require.Equal(t, DefaultAuthorRole, doc.AuthorRole)
+
+	// The supplier is serialized only when set
+	var b bytes.Buffer
+	require.NoError(t, doc.ToJSON(&b))
+	require.Contains(t, b.String(), `"supplier": "Example Company"`)
+
+	doc.Supplier = ""
+	b.Reset()
+	require.NoError(t, doc.ToJSON(&b))
+	require.NotContains(t, b.String(), `"supplier"`)
+}
+
+func TestOpenCSAFHashes(t *testing.T) {
+	// go-vex fails to decode identification helpers with hashes
+	_, err := vex.OpenCSAF("testdata/csaf/csaf-hashes.json", nil)
+	require.Error(t, err)
+
+	doc, err := OpenCSAF("testdata/csaf/csaf-hashes.json", nil)
+	require.NoError(t, err)
+	require.Len(t, doc.Statements, 1)
+	require.Equal(t, map[vex.Algorithm]vex.Hash{
+		vex.SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}, doc.Statements[0].Products[0].Hashes)
+
+	// Artifacts can be matched by their computed hash
+	ss := StatementsForHash(doc, vex.SHA256, "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855")
+	require.Len(t, ss, 1)
+	require.Equal(t, "CVE-2009-4487", string(ss[0].Vulnerability.Name))
+	require.Empty(t, StatementsForHash(doc, vex.SHA256, "0000"))
+	require.Empty(t, StatementsForHash(doc, vex.SHA512, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+}
+
+func TestOpenCSAFRedHat(t *testing.T) {
+	doc, err := OpenCSAF("testdata/csaf/redhat.json", nil)
+	require.NoError(t, err)
+	require.Len(t, doc.Statements, 2)
+	for i := range doc.Statements {
+		require.NoError(t, doc.Statements[i].Validate(), "statement #%d", i)
+	}
+
+	// Statements are built for the composite products of the relationships
+	require.Equal(t, "CVE-2023-4244", string(doc.Statements[0].Vulnerability.Name))
+	require.Equal(t, vex.StatusFixed, doc.Statements[0].Status)
+	require.Equal(t, "red_hat_enterprise_linux_9:kernel-0:5.14.0-284.40.1.el9_2", doc.Statements[0].Products[0].ID)
+	require.Empty(t, doc.Statements[0].Justification)
+	require.Empty(t, doc.Statements[0].ActionStatement)
+
+	// Flags are read as justifications
+	require.Equal(t, vex.StatusNotAffected, doc.Statements[1].Status)
+	require.Equal(t, "red_hat_enterprise_linux_6:kernel", doc.Statements[1].Products[0].ID)
+	require.Equal(t, vex.VulnerableCodeNotPresent, doc.Statements[1].Justification)
+
+	// Composite products are matched by the helpers of their components
+	doc, err = OpenCSAF("testdata/csaf/redhat.json", []string{"pkg:rpm/redhat/kernel"})
+	require.NoError(t, err)
+	require.Len(t, doc.Statements, 1)
+	require.Equal(t, "red_hat_enterprise_linux_6:kernel", doc.Statements[0].Products[0].ID)
+}
+
+func TestOpenCSAFUnderInvestigation(t *testing.T) {
+	doc, err := OpenCSAF("testdata/csaf/csaf-under-investigation.json", nil)
+	require.NoError(t, err)
+	require.Len(t, doc.Statements, 2)
+
+	require.Equal(t, vex.StatusFixed, doc.Statements[0].Status)
+	require.Equal(t, "INTERNAL-0001", doc.Statements[0].Products[0].ID)
+
+	s := doc.Statements[1]
+	require.Equal(t, "CVE-2023-0002", string(s.Vulnerability.Name))
+	require.Equal(t, vex.StatusUnderInvestigation, s.Status)
+	require.Equal(t, "CSAFPID-0001", s.Products[0].ID)
+	require.Empty(t, s.ActionStatement)
+	require.Empty(t, s.Justification)
+	require.NoError(t, s.Validate())
+}
+
+func TestOpenCSAFLint(t *testing.T) {
+	for _, path := range []string{"testdata/csaf/csaf.json", "testdata/csaf/redhat.json"} {
+		doc, err := OpenCSAF(path, nil)
+		require.NoError(t, err)
+		// Imported documents get the OpenVEX context and valid statements
+		for _, f := range Lint(doc) {
+			require.NotEqual(t, "/@context", f.Pointer, "%s: %s", path, f.Message)
+			require.NotEqual(t, LevelError, f.Level, "%s: %s", path, f.Message)
+		}
+	}
+
+	// The threat details explain the impact of not_affected products
+	doc, err := OpenCSAF("testdata/csaf/csaf.json", nil)
+	require.NoError(t, err)
+	require.Equal(t, vex.StatusNotAffected, doc.Statements[0].Status)
+	require.Equal(t, "Class with vulnerable code was removed before shipping.", doc.Statements[0].ImpactStatement)
+	require.Empty(t, doc.Statements[0].ActionStatement)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// csvHeader lists the columns of the CSV worklist
+var csvHeader = []string{
+	"vulnerability", "product", "status", "justification", "action_statement", "timestamp",
+}
+
+// ToCSV writes the statements of a document as a CSV triage worklist, with a
+// header row and one row per statement and product. Statements listing more
+// than one product are repeated in a row for each of them, so each row can be
+// tracked on its own. Statements without products get one row with an empty
+// product. Statements without a timestamp take the one of the document.
+func ToCSV(doc *vex.VEX, w io.Writer) error {
+	return ToCSVWithOptions(doc, w, &ExportOptions{})
+}
+
+// ToCSVWithOptions works like ToCSV, translating the statuses and
+// justifications with the vocabulary in the options.
+func ToCSVWithOptions(doc *vex.VEX, w io.Writer, opts *ExportOptions) error {
+	vocabulary := opts.vocabulary()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("writing CSV header: %w", err)
+	}
+
+	for i := range doc.Statements {
+		s := cascadeTimestamp(doc, doc.Statements[i])
+		ts := ""
+		if s.Timestamp != nil {
+			ts = s.Timestamp.UTC().Format(time.RFC3339)
+		}
+
+		products := []string{}
+		for j := range s.Products {
+			products = append(products, productIdentifier(&s.Products[j]))
+		}
+		if len(products) == 0 {
+			products = append(products, "")
+		}
+
+		for _, product := range products {
+			if err := cw.Write([]string{
+				string(s.Vulnerability.Name), product, vocabulary.Status(s.Status),
+				vocabulary.Justification(s.Justification), s.ActionStatement, ts,
+			}); err != nil {
+				return fmt.Errorf("writing CSV row: %w", err)
+			}
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	return nil
+}
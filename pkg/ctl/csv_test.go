@@ -0,0 +1,38 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestToCSV(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 12, 30, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+
+	notAffected := testStatement("CVE-2023-0001", bash, vex.StatusNotAffected, t1)
+	notAffected.Products = append(notAffected.Products, vex.Product{Component: vex.Component{ID: "pkg:apk/wolfi/git@2.0.0"}})
+	affected := testStatement("CVE-2023-0002", bash, vex.StatusAffected, t2)
+	affected.ActionStatement = "Upgrade to 1.0.1, or disable the module, \"mod_x\""
+	undated := testStatement("CVE-2023-0003", bash, vex.StatusUnderInvestigation, t1)
+	undated.Timestamp = nil
+
+	doc := testDocument(t1, notAffected, affected, undated)
+
+	var b bytes.Buffer
+	require.NoError(t, ToCSV(doc, &b))
+	golden, err := os.ReadFile("testdata/csv/worklist.csv")
+	require.NoError(t, err)
+	require.Equal(t, string(golden), b.String())
+}
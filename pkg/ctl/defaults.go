@@ -0,0 +1,33 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+var (
+	// DefaultAuthor is the author FillDefaults records in documents that
+	// don't have one. It can be overridden to brand documents produced by
+	// tools built on vexctl.
+	DefaultAuthor = vex.DefaultAuthor
+
+	// DefaultAuthorRole is the role FillDefaults records in documents that
+	// don't have one.
+	DefaultAuthorRole = vex.DefaultRole
+)
+
+// FillDefaults sets the DefaultAuthor and DefaultAuthorRole in a document
+// when its author or role are empty. Documents are filled before writing
+// them out so they never get serialized without an author.
+func FillDefaults(doc *vex.VEX) {
+	if doc.Author == "" {
+		doc.Author = DefaultAuthor
+	}
+	if doc.AuthorRole == "" {
+		doc.AuthorRole = DefaultAuthorRole
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestFillDefaults(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Author-less documents serialize with the default author
+	doc := testDocument(ts)
+	doc.Author = ""
+	FillDefaults(doc)
+	var b bytes.Buffer
+	require.NoError(t, doc.ToJSON(&b))
+	parsed, err := vex.Parse(b.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, vex.DefaultAuthor, parsed.Author)
+
+	// Existing values are kept
+	doc = testDocument(ts)
+	doc.Author = "Jane Doe"
+	doc.AuthorRole = "Maintainer"
+	FillDefaults(doc)
+	require.Equal(t, "Jane Doe", doc.Author)
+	require.Equal(t, "Maintainer", doc.AuthorRole)
+
+	// The defaults can be overridden
+	origAuthor, origRole := DefaultAuthor, DefaultAuthorRole
+	defer func() { DefaultAuthor, DefaultAuthorRole = origAuthor, origRole }()
+	DefaultAuthor, DefaultAuthorRole = "ACME Security", "Vendor"
+
+	doc = testDocument(ts)
+	doc.Author, doc.AuthorRole = "", ""
+	FillDefaults(doc)
+	require.Equal(t, "ACME Security", doc.Author)
+	require.Equal(t, "Vendor", doc.AuthorRole)
+
+	// New documents get the overridden defaults too
+	newDoc := NewDocument()
+	require.Equal(t, "ACME Security", newDoc.Author)
+	require.Equal(t, "Vendor", newDoc.AuthorRole)
+	built, err := NewBuilder().Fixed("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0").Build()
+	require.NoError(t, err)
+	require.Equal(t, "ACME Security", built.Author)
+}
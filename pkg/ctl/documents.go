@@ -0,0 +1,294 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// DeltaSince returns a new document containing only the statements in doc
+// that are new or have changed with respect to the baseline document. The
+// returned document keeps the metadata of doc, so it can be published as a
+// regular VEX document to consumers that already have the baseline.
+func DeltaSince(doc, baseline *vex.VEX) *vex.VEX {
+	return DeltaSinceWithKey(doc, baseline, EqualityKey())
+}
+
+// DeltaSinceWithKey works like DeltaSince but compares the statements using
+// a key function, usually built with EqualityKey, so changes to the fields
+// left out of the key are not reported.
+func DeltaSinceWithKey(doc, baseline *vex.VEX, key func(vex.Statement) string) *vex.VEX {
+	known := map[string]struct{}{}
+	if baseline != nil {
+		for i := range baseline.Statements {
+			known[key(cascadeTimestamp(baseline, baseline.Statements[i]))] = struct{}{}
+		}
+	}
+
+	delta := &vex.VEX{
+		Metadata:   doc.Metadata,
+		Statements: []vex.Statement{},
+	}
+
+	for i := range doc.Statements {
+		// Timestamps are cascaded from the documents before comparing. This
+		// ensures the statements in the delta preserve their time context
+		// when applied on top of the baseline.
+		s := cascadeTimestamp(doc, doc.Statements[i])
+		if _, ok := known[key(s)]; ok {
+			continue
+		}
+		delta.Statements = append(delta.Statements, s)
+	}
+
+	return delta
+}
+
+// EachStatement calls fn with the index and a copy of each statement in a
+// document, in order, until fn returns false. As fn gets copies, it cannot
+// modify the document; use UpdateStatement for that.
+func EachStatement(doc *vex.VEX, fn func(i int, s vex.Statement) bool) {
+	for i := range doc.Statements {
+		if !fn(i, doc.Statements[i]) {
+			return
+		}
+	}
+}
+
+// GroupByID returns the documents in a corpus grouped by their ID, so the
+// revisions of each document can be found. Each group is sorted from the
+// oldest to the newest revision. Documents without an ID are grouped under
+// the empty string.
+func GroupByID(docs []*vex.VEX) map[string][]*vex.VEX {
+	groups := map[string][]*vex.VEX{}
+	for _, doc := range docs {
+		groups[doc.ID] = append(groups[doc.ID], doc)
+	}
+	for _, group := range groups {
+		vex.SortDocuments(group)
+	}
+	return groups
+}
+
+// LatestByID returns the documents in a corpus dropping the superseded
+// revisions of each document ID, so only the newest one is kept. Documents
+// are returned in the order their IDs first appear in the corpus. Documents
+// without an ID cannot be related to other revisions, so all of them are
+// kept.
+func LatestByID(docs []*vex.VEX) []*vex.VEX {
+	groups := GroupByID(docs)
+	latest := []*vex.VEX{}
+	seen := map[string]struct{}{}
+	for _, doc := range docs {
+		if doc.ID == "" {
+			latest = append(latest, doc)
+			continue
+		}
+		if _, ok := seen[doc.ID]; ok {
+			continue
+		}
+		seen[doc.ID] = struct{}{}
+		group := groups[doc.ID]
+		latest = append(latest, group[len(group)-1])
+	}
+	return latest
+}
+
+// VerifyVersionChain checks that the revisions of each document ID have
+// strictly increasing versions as their timestamps increase and returns the
+// sorted IDs where a newer revision has the same or an older version than
+// the previous one. Revisions with the same timestamp only need different
+// versions, as their order cannot be told. Documents without an ID or a
+// version are not part of any chain and are ignored.
+func VerifyVersionChain(docs []*vex.VEX) []string {
+	broken := []string{}
+	for id, group := range GroupByID(docs) {
+		if id == "" {
+			continue
+		}
+		var prev *vex.VEX
+		for _, doc := range group {
+			if doc.Version == 0 {
+				continue
+			}
+			if prev != nil && !versionFollows(prev, doc) {
+				broken = append(broken, id)
+				break
+			}
+			prev = doc
+		}
+	}
+	sort.Strings(broken)
+	return broken
+}
+
+// versionFollows returns true if the version of a revision follows the one
+// of the revision before it
+func versionFollows(prev, doc *vex.VEX) bool {
+	if prev.Timestamp != nil && doc.Timestamp != nil && prev.Timestamp.Equal(*doc.Timestamp) {
+		return prev.Version != doc.Version
+	}
+	return doc.Version > prev.Version
+}
+
+// RelevantDocuments returns the documents in a corpus that have statements
+// about a vulnerability and product, sorted chronologically. These are the
+// only documents that influence the effective status of the pair, so callers
+// loading from large stores can fetch and parse just them. Statements are
+// matched the same way EffectiveStatuses and IsAffected match them.
+func RelevantDocuments(docs []*vex.VEX, vulnID, productID string) []*vex.VEX {
+	relevant := []*vex.VEX{}
+	for _, doc := range docs {
+		for i := range doc.Statements {
+			s := &doc.Statements[i]
+			if s.Vulnerability.Matches(vulnID) && statementMatches(s, productID, DefaultProductMatcher) {
+				relevant = append(relevant, doc)
+				break
+			}
+		}
+	}
+	vex.SortDocuments(relevant)
+	return relevant
+}
+
+// ScopeFingerprint returns a hash of the vulnerability and product pairs a
+// document has statements about, ignoring their statuses and any other data.
+// Documents with the same fingerprint cover the same ground, which makes it
+// a cheap way to tell revisions of a document from unrelated ones. The order
+// of the statements does not change the fingerprint.
+func ScopeFingerprint(doc *vex.VEX) string {
+	pairs := []string{}
+	for i := range doc.Statements {
+		vuln := string(doc.Statements[i].Vulnerability.Name)
+		for j := range doc.Statements[i].Products {
+			pairs = append(pairs, vuln+"\x00"+productIdentifier(&doc.Statements[i].Products[j]))
+		}
+	}
+	pairs = uniqueSorted(pairs)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(pairs, "\n"))))
+}
+
+// CheckTimestamps reports the documents in a corpus whose dates would make
+// sorting them unreliable: documents without a timestamp, with a zero value
+// timestamp (as the ones produced when importing CSAF), with a timestamp in
+// the future and those last updated before they were issued. Each problem
+// found is returned as a message, the list is empty if all dates are sane.
+func CheckTimestamps(docs []*vex.VEX) []string {
+	now := TimeSource()
+	problems := []string{}
+	for i, doc := range docs {
+		name := fmt.Sprintf("document #%d", i)
+		if doc.ID != "" {
+			name = fmt.Sprintf("%s (%s)", name, doc.ID)
+		}
+
+		switch {
+		case doc.Timestamp == nil:
+			problems = append(problems, fmt.Sprintf("%s has no timestamp", name))
+		case doc.Timestamp.IsZero():
+			problems = append(problems, fmt.Sprintf("%s has a zero value timestamp", name))
+		case doc.Timestamp.After(now):
+			problems = append(problems, fmt.Sprintf("%s has a timestamp in the future (%s)", name, doc.Timestamp.Format(time.RFC3339)))
+		}
+
+		if doc.Timestamp != nil && doc.LastUpdated != nil && doc.LastUpdated.Before(*doc.Timestamp) {
+			problems = append(problems, fmt.Sprintf(
+				"%s was last updated (%s) before its timestamp (%s)", name,
+				doc.LastUpdated.Format(time.RFC3339), doc.Timestamp.Format(time.RFC3339),
+			))
+		}
+	}
+	return problems
+}
+
+// VerifyChronology checks that the dates in a document are consistent with
+// each other: the document is not last updated before it was issued, and no
+// statement is issued, updated or has its action statement dated after the
+// document was last updated (or issued, if it was never updated) nor is
+// updated before it was issued. Each inconsistency is returned as a message,
+// the list is empty if the dates are consistent.
+func VerifyChronology(doc *vex.VEX) []string {
+	problems := []string{}
+	for _, f := range chronologyFindings(doc) {
+		problems = append(problems, f.Message)
+	}
+	return problems
+}
+
+// chronologyFindings returns the chronology inconsistencies of a document as
+// lint findings pointing to the offending dates
+func chronologyFindings(doc *vex.VEX) []Finding {
+	findings := []Finding{}
+	add := func(pointer, format string, args ...any) {
+		findings = append(findings, Finding{
+			Level: LevelWarning, Message: fmt.Sprintf(format, args...), Pointer: pointer,
+		})
+	}
+	format := func(t *time.Time) string { return t.Format(time.RFC3339) }
+
+	latest := doc.Timestamp
+	if doc.LastUpdated != nil {
+		if doc.Timestamp != nil && doc.LastUpdated.Before(*doc.Timestamp) {
+			add("/last_updated", "document was last updated (%s) before its timestamp (%s)",
+				format(doc.LastUpdated), format(doc.Timestamp))
+		}
+		latest = doc.LastUpdated
+	}
+
+	for i := range doc.Statements {
+		s := &doc.Statements[i]
+		if s.Timestamp != nil && s.LastUpdated != nil && s.LastUpdated.Before(*s.Timestamp) {
+			add(fmt.Sprintf("/statements/%d/last_updated", i),
+				"statement #%d was last updated (%s) before its timestamp (%s)",
+				i, format(s.LastUpdated), format(s.Timestamp))
+		}
+		if latest == nil {
+			continue
+		}
+		for _, date := range []struct {
+			field string
+			t     *time.Time
+		}{
+			{"timestamp", s.Timestamp},
+			{"last_updated", s.LastUpdated},
+			{"action_statement_timestamp", s.ActionStatementTimestamp},
+		} {
+			if date.t != nil && date.t.After(*latest) {
+				add(fmt.Sprintf("/statements/%d/%s", i, date.field),
+					"statement #%d %s (%s) is newer than the document (%s)",
+					i, date.field, format(date.t), format(latest))
+			}
+		}
+	}
+	return findings
+}
+
+// cascadeTimestamp returns a copy of the statement with the document's
+// timestamp set when the statement does not have one of its own.
+func cascadeTimestamp(doc *vex.VEX, s vex.Statement) vex.Statement { //nolint:gocritic // this IS supposed to copy
+	if s.Timestamp == nil {
+		s.Timestamp = doc.Timestamp
+	}
+	return s
+}
+
+// statementKey returns a string that captures all the data in a statement.
+// Two statements with the same key are considered equal.
+func statementKey(s vex.Statement) string { //nolint:gocritic // passed by value to match the statement loops
+	data, err := json.Marshal(s)
+	if err != nil {
+		// Statements are plain data, they always marshal
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func testStatement(vuln, product string, status vex.Status, ts time.Time) vex.Statement {
+	s := vex.Statement{
+		Vulnerability: vex.Vulnerability{Name: vex.VulnerabilityID(vuln)},
+		Products:      []vex.Product{{Component: vex.Component{ID: product}}},
+		Status:        status,
+		Timestamp:     &ts,
+	}
+	switch status {
+	case vex.StatusAffected:
+		s.ActionStatement = "Upgrade to the latest version"
+	case vex.StatusNotAffected:
+		s.Justification = vex.ComponentNotPresent
+	}
+	return s
+}
+
+func TestDeltaSince(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	baseline := vex.New()
+	baseline.Timestamp = &t1
+	baseline.Statements = []vex.Statement{
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusUnderInvestigation, t1),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusUnderInvestigation, t1),
+	}
+
+	current := vex.New()
+	current.Timestamp = &t2
+	current.Statements = []vex.Statement{
+		baseline.Statements[0],
+		baseline.Statements[1],
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusNotAffected, t2),
+		testStatement("CVE-2023-0003", "pkg:apk/wolfi/bash@1.0.0", vex.StatusAffected, t2),
+	}
+
+	for _, tc := range []struct {
+		name     string
+		baseline *vex.VEX
+		expected int
+	}{
+		{"no baseline", nil, 4},
+		{"same document", &current, 0},
+		{"new and changed statements", &baseline, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			delta := DeltaSince(&current, tc.baseline)
+			require.NotNil(t, delta)
+			require.Equal(t, current.Metadata, delta.Metadata)
+			require.Len(t, delta.Statements, tc.expected)
+		})
+	}
+
+	// Applying the delta onto the baseline must produce the current data
+	delta := DeltaSince(&current, &baseline)
+	merged, err := vex.MergeDocuments([]*vex.VEX{&baseline, delta})
+	require.NoError(t, err)
+	require.Len(t, merged.Statements, len(current.Statements))
+	for _, vuln := range []string{"CVE-2023-0001", "CVE-2023-0002", "CVE-2023-0003"} {
+		expected := current.Matches(vuln, "pkg:apk/wolfi/bash@1.0.0", nil)
+		got := merged.Matches(vuln, "pkg:apk/wolfi/bash@1.0.0", nil)
+		require.NotEmpty(t, got)
+		require.Equal(t, expected[len(expected)-1].Status, got[len(got)-1].Status, vuln)
+	}
+}
+
+func TestEachStatement(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusAffected, ts),
+		testStatement("CVE-2023-0003", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+	)
+
+	visited := []int{}
+	EachStatement(doc, func(i int, s vex.Statement) bool { //nolint:gocritic // this IS supposed to copy
+		require.Equal(t, doc.Statements[i], s)
+		visited = append(visited, i)
+		return true
+	})
+	require.Equal(t, []int{0, 1, 2}, visited)
+
+	// Returning false stops the iteration, and changes to the statements
+	// are not written back
+	visited = []int{}
+	EachStatement(doc, func(i int, s vex.Statement) bool { //nolint:gocritic // this IS supposed to copy
+		visited = append(visited, i)
+		s.Status = vex.StatusNotAffected
+		return s.Vulnerability.Name != "CVE-2023-0002"
+	})
+	require.Equal(t, []int{0, 1}, visited)
+	require.Equal(t, vex.StatusAffected, doc.Statements[1].Status)
+}
+
+func TestLatestByID(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	t3 := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	revision := func(id string, version int, ts time.Time) *vex.VEX {
+		doc := testDocument(ts)
+		doc.ID = id
+		doc.Version = version
+		return doc
+	}
+	r2 := revision("doc-a", 2, t2)
+	r3 := revision("doc-a", 3, t3)
+	r1 := revision("doc-a", 1, t1)
+	other := revision("doc-b", 1, t1)
+	docs := []*vex.VEX{r2, other, r3, r1}
+
+	groups := GroupByID(docs)
+	require.Len(t, groups, 2)
+	require.Equal(t, []*vex.VEX{r1, r2, r3}, groups["doc-a"])
+	require.Equal(t, []*vex.VEX{other}, groups["doc-b"])
+
+	require.Equal(t, []*vex.VEX{r3, other}, LatestByID(docs))
+
+	// The input slice is not reordered
+	require.Equal(t, []*vex.VEX{r2, other, r3, r1}, docs)
+}
+
+func TestVerifyVersionChain(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	t3 := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	revision := func(id string, version int, ts time.Time) *vex.VEX {
+		doc := testDocument(ts)
+		doc.ID = id
+		doc.Version = version
+		return doc
+	}
+
+	for _, tc := range []struct {
+		name     string
+		docs     []*vex.VEX
+		expected []string
+	}{
+		{"increasing", []*vex.VEX{revision("doc-a", 2, t2), revision("doc-a", 1, t1), revision("doc-a", 5, t3)}, []string{}},
+		{"regression", []*vex.VEX{revision("doc-a", 1, t1), revision("doc-a", 3, t2), revision("doc-a", 2, t3)}, []string{"doc-a"}},
+		{"duplicate", []*vex.VEX{revision("doc-a", 1, t1), revision("doc-a", 1, t2)}, []string{"doc-a"}},
+		{"same timestamp", []*vex.VEX{revision("doc-a", 1, t1), revision("doc-a", 2, t1)}, []string{}},
+		{"unversioned", []*vex.VEX{revision("doc-a", 1, t1), revision("doc-a", 0, t2), revision("doc-a", 2, t3)}, []string{}},
+		{"no id", []*vex.VEX{revision("", 2, t1), revision("", 1, t2)}, []string{}},
+		{
+			"several ids",
+			[]*vex.VEX{revision("doc-b", 2, t1), revision("doc-b", 1, t2), revision("doc-a", 1, t1), revision("doc-a", 1, t3)},
+			[]string{"doc-a", "doc-b"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, VerifyVersionChain(tc.docs))
+		})
+	}
+}
+
+func TestRelevantDocuments(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	t3 := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+
+	fixed := testDocument(t3, testStatement("CVE-2023-0001", bash, vex.StatusFixed, t3))
+	affected := testDocument(t1,
+		testStatement("CVE-2023-0002", bash, vex.StatusAffected, t1),
+		testStatement("CVE-2023-0001", bash, vex.StatusAffected, t1),
+	)
+	otherVuln := testDocument(t2, testStatement("CVE-2023-0002", bash, vex.StatusFixed, t2))
+	otherProduct := testDocument(t2, testStatement("CVE-2023-0001", "pkg:apk/wolfi/git@1.0.0", vex.StatusFixed, t2))
+	docs := []*vex.VEX{fixed, otherVuln, affected, otherProduct}
+
+	require.Equal(t, []*vex.VEX{affected, fixed}, RelevantDocuments(docs, "CVE-2023-0001", bash))
+	require.Empty(t, RelevantDocuments(docs, "CVE-2023-0003", bash))
+
+	// The input slice is not reordered
+	require.Equal(t, []*vex.VEX{fixed, otherVuln, affected, otherProduct}, docs)
+}
+
+func TestScopeFingerprint(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	s1 := testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusUnderInvestigation, ts)
+	s2 := testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusAffected, ts)
+
+	fingerprint := ScopeFingerprint(testDocument(ts, s1, s2))
+	require.Len(t, fingerprint, 64)
+
+	// Reordering the statements or changing their status keeps the scope
+	require.Equal(t, fingerprint, ScopeFingerprint(testDocument(ts, s2, s1)))
+	fixed := testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts.Add(time.Hour))
+	require.Equal(t, fingerprint, ScopeFingerprint(testDocument(ts, s2, fixed)))
+
+	// Covering other products changes it
+	s3 := testStatement("CVE-2023-0002", "pkg:apk/wolfi/git@2.0.0", vex.StatusAffected, ts)
+	require.NotEqual(t, fingerprint, ScopeFingerprint(testDocument(ts, s1, s3)))
+}
+
+func TestVerifyChronology(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	t3 := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	doc := testDocument(t1,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, t1),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, t2),
+	)
+	doc.LastUpdated = &t2
+	require.Empty(t, VerifyChronology(doc))
+	require.Empty(t, Lint(doc))
+
+	// A statement newer than the last update of the document
+	doc.Statements[1].LastUpdated = &t3
+	problems := VerifyChronology(doc)
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0], "statement #1 last_updated")
+	findings := Lint(doc)
+	require.Len(t, findings, 1)
+	require.Equal(t, LevelWarning, findings[0].Level)
+	require.Equal(t, "/statements/1/last_updated", findings[0].Pointer)
+
+	// Without updates, statements are checked against the document timestamp
+	doc.LastUpdated = nil
+	doc.Statements[1].LastUpdated = nil
+	require.Len(t, VerifyChronology(doc), 1)
+
+	// Dates going backwards
+	doc.LastUpdated = &t1
+	doc.Timestamp = &t2
+	doc.Statements[1].LastUpdated = &t1
+	require.Len(t, VerifyChronology(doc), 3)
+}
+
+func TestCheckTimestamps(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := t1.Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+
+	for _, tc := range []struct {
+		name     string
+		prepare  func(*vex.VEX)
+		expected string
+	}{
+		{"valid", func(*vex.VEX) {}, ""},
+		{"nil timestamp", func(doc *vex.VEX) { doc.Timestamp = nil }, "has no timestamp"},
+		{"zero timestamp", func(doc *vex.VEX) { doc.Timestamp = &time.Time{} }, "zero value timestamp"},
+		{"future timestamp", func(doc *vex.VEX) { doc.Timestamp = &future }, "in the future"},
+		{"updated before issued", func(doc *vex.VEX) { doc.LastUpdated = &before }, "last updated"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := testDocument(t1)
+			doc.ID = "test-doc"
+			tc.prepare(doc)
+			problems := CheckTimestamps([]*vex.VEX{testDocument(t1), doc})
+			if tc.expected == "" {
+				require.Empty(t, problems)
+				return
+			}
+			require.Len(t, problems, 1)
+			require.Contains(t, problems[0], "document #1 (test-doc)")
+			require.Contains(t, problems[0], tc.expected)
+		})
+	}
+
+	// Documents imported from CSAF get a zero timestamp
+	csafDoc, err := OpenCSAF("testdata/csaf/csaf.json", nil)
+	require.NoError(t, err)
+	require.Len(t, CheckTimestamps([]*vex.VEX{csafDoc}), 1)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// StatementField names one of the fields of a VEX statement that can be
+// considered when comparing statements
+type StatementField string
+
+const (
+	FieldID                       StatementField = "@id"
+	FieldVulnerability            StatementField = "vulnerability"
+	FieldTimestamp                StatementField = "timestamp"
+	FieldLastUpdated              StatementField = "last_updated"
+	FieldProducts                 StatementField = "products"
+	FieldStatus                   StatementField = "status"
+	FieldStatusNotes              StatementField = "status_notes"
+	FieldJustification            StatementField = "justification"
+	FieldImpactStatement          StatementField = "impact_statement"
+	FieldActionStatement          StatementField = "action_statement"
+	FieldActionStatementTimestamp StatementField = "action_statement_timestamp"
+)
+
+// StatementFields is the full set of statement fields. It is the set
+// EqualityKey uses by default.
+var StatementFields = []StatementField{
+	FieldID, FieldVulnerability, FieldTimestamp, FieldLastUpdated,
+	FieldProducts, FieldStatus, FieldStatusNotes, FieldJustification,
+	FieldImpactStatement, FieldActionStatement, FieldActionStatementTimestamp,
+}
+
+// EqualityKey returns a function that computes a key of the listed fields of
+// a statement. Two statements with the same key are considered equal, so
+// callers can choose which fields define the identity of a statement, for
+// example to ignore notes when deduplicating. If no fields are listed, all
+// of them are used.
+func EqualityKey(fields ...StatementField) func(vex.Statement) string {
+	if len(fields) == 0 {
+		fields = StatementFields
+	}
+	keep := map[StatementField]struct{}{}
+	for _, f := range fields {
+		keep[f] = struct{}{}
+	}
+
+	return func(s vex.Statement) string { //nolint:gocritic // this IS supposed to copy
+		return statementKey(keepFields(s, keep))
+	}
+}
+
+// keepFields returns a copy of a statement with all the fields not in the
+// set cleared
+func keepFields(s vex.Statement, keep map[StatementField]struct{}) vex.Statement { //nolint:gocritic // this IS supposed to copy
+	kept := vex.Statement{}
+	for f := range keep {
+		switch f {
+		case FieldID:
+			kept.ID = s.ID
+		case FieldVulnerability:
+			kept.Vulnerability = s.Vulnerability
+		case FieldTimestamp:
+			kept.Timestamp = s.Timestamp
+		case FieldLastUpdated:
+			kept.LastUpdated = s.LastUpdated
+		case FieldProducts:
+			kept.Products = s.Products
+		case FieldStatus:
+			kept.Status = s.Status
+		case FieldStatusNotes:
+			kept.StatusNotes = s.StatusNotes
+		case FieldJustification:
+			kept.Justification = s.Justification
+		case FieldImpactStatement:
+			kept.ImpactStatement = s.ImpactStatement
+		case FieldActionStatement:
+			kept.ActionStatement = s.ActionStatement
+		case FieldActionStatementTimestamp:
+			kept.ActionStatementTimestamp = s.ActionStatementTimestamp
+		}
+	}
+	return kept
+}
+
+// EqualOptions control how documents are compared by EqualWithOptions
+type EqualOptions struct {
+	// IgnoreMetadata compares only the statements of the documents,
+	// ignoring their ID, dates, version, author and the rest of their
+	// metadata.
+	IgnoreMetadata bool
+}
+
+// Equal returns true if two documents are semantically identical: they have
+// the same metadata and the same statements once normalized (see
+// Normalize), regardless of the order of their statements, products and
+// aliases. Statements without a timestamp are compared with the one of
+// their document.
+func Equal(a, b *vex.VEX) bool {
+	return EqualWithOptions(a, b, &EqualOptions{})
+}
+
+// EqualWithOptions compares two documents like Equal using the options. Nil
+// options use the defaults.
+func EqualWithOptions(a, b *vex.VEX, opts *EqualOptions) bool {
+	if opts == nil {
+		opts = &EqualOptions{}
+	}
+	if !opts.IgnoreMetadata {
+		ma, errA := json.Marshal(a.Metadata)
+		mb, errB := json.Marshal(b.Metadata)
+		if errA != nil || errB != nil || string(ma) != string(mb) {
+			return false
+		}
+	}
+	return slices.Equal(equalityKeys(a), equalityKeys(b))
+}
+
+// equalityKeys returns the sorted keys of the normalized statements of a
+// document, with their products sorted as in StatementDigest and their
+// aliases sorted
+func equalityKeys(doc *vex.VEX) []string {
+	normal := Normalize(doc, &NormalizeOptions{PreserveOrder: true})
+	keys := make([]string, 0, len(normal.Statements))
+	for _, s := range normal.Statements { //nolint:gocritic // this IS supposed to copy
+		s = cascadeTimestamp(doc, s)
+		s.Products = slices.Clone(s.Products)
+		sort.SliceStable(s.Products, func(i, j int) bool {
+			return canonicalKey(&s.Products[i].Component, &s.Products[i]) < canonicalKey(&s.Products[j].Component, &s.Products[j])
+		})
+		s.Vulnerability.Aliases = slices.Clone(s.Vulnerability.Aliases)
+		slices.Sort(s.Vulnerability.Aliases)
+		keys = append(keys, statementKey(s))
+	}
+	sort.Strings(keys)
+	return keys
+}
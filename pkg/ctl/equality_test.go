@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestEqualityKey(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+
+	s1 := testStatement("CVE-2023-0001", product, vex.StatusFixed, ts)
+	s1.StatusNotes = "Fixed in 1.0.0"
+	s2 := s1
+	s2.StatusNotes = "Fixed by bumping the dependency"
+
+	// The default key considers all fields
+	require.Equal(t, statementKey(s1), EqualityKey()(s1))
+	require.NotEqual(t, EqualityKey()(s1), EqualityKey()(s2))
+
+	// Leaving out the notes makes the statements equal
+	noNotes := EqualityKey(FieldVulnerability, FieldProducts, FieldStatus, FieldTimestamp)
+	require.Equal(t, noNotes(s1), noNotes(s2))
+
+	doc := vex.New()
+	doc.Statements = []vex.Statement{s1, s2}
+	require.Len(t, Deduplicate(&doc).Statements, 2)
+	deduped := DeduplicateWithKey(&doc, noNotes)
+	require.Len(t, deduped.Statements, 1)
+	require.Equal(t, s1, deduped.Statements[0])
+
+	baseline := vex.New()
+	baseline.Statements = []vex.Statement{s1}
+	current := vex.New()
+	current.Statements = []vex.Statement{s2}
+	require.Len(t, DeltaSince(&current, &baseline).Statements, 1)
+	require.Empty(t, DeltaSinceWithKey(&current, &baseline, noNotes).Statements)
+}
+
+func TestEqual(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	s1 := testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)
+	s1.Products = append(s1.Products, vex.Product{Component: vex.Component{ID: "pkg:apk/wolfi/git@1.0.0"}})
+	s2 := testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusAffected, ts)
+	a := testDocument(ts, s1, s2)
+
+	// Reordering statements and products and changing the formatting of
+	// identifiers does not change the document
+	r1 := testStatement("cve-2023-0001", "pkg:apk/wolfi/git@1.0.0", vex.StatusFixed, ts)
+	r1.Products = append(r1.Products, vex.Product{Component: vex.Component{ID: "pkg:APK/wolfi/bash@1.0.0"}})
+	r2 := s2
+	r2.Timestamp = nil
+	b := testDocument(ts, s2, r1)
+	b.Statements[0] = r2
+	require.True(t, Equal(a, b))
+
+	// Products identified only by their purl are compared in any order too
+	purl := func(id string) vex.Product {
+		return vex.Product{Component: vex.Component{Identifiers: map[vex.IdentifierType]string{vex.PURL: id}}}
+	}
+	p1 := testStatement("CVE-2023-0003", "", vex.StatusFixed, ts)
+	p1.Products = []vex.Product{purl("pkg:apk/wolfi/bash@1.0.0"), purl("pkg:apk/wolfi/git@1.0.0")}
+	p2 := p1
+	p2.Products = []vex.Product{p1.Products[1], p1.Products[0]}
+	require.True(t, Equal(testDocument(ts, p1), testDocument(ts, p2)))
+
+	// Metadata is compared unless ignored
+	b.ID = "another-id"
+	later := ts.Add(time.Hour)
+	b.Timestamp = &later
+	b.Statements[0].Timestamp = &ts
+	require.False(t, Equal(a, b))
+	require.False(t, EqualWithOptions(a, b, nil))
+	require.True(t, EqualWithOptions(a, b, &EqualOptions{IgnoreMetadata: true}))
+
+	// Different statements make the documents differ
+	c := testDocument(ts, s1)
+	require.False(t, EqualWithOptions(a, c, &EqualOptions{IgnoreMetadata: true}))
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// ghsaAdvisory captures the fields vexctl reads from a GitHub Security
+// Advisory, as returned by the GitHub REST API
+type ghsaAdvisory struct {
+	GHSAID      string `json:"ghsa_id"`
+	CVEID       string `json:"cve_id"`
+	HTMLURL     string `json:"html_url"`
+	Summary     string `json:"summary"`
+	Identifiers []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"identifiers"`
+}
+
+// OpenGHSA reads a GitHub Security Advisory in the JSON format of the GitHub
+// REST API and builds a VEX document to start triaging it: the document has
+// an under_investigation statement about the advisory for each of the
+// products. The statements are keyed by the GHSA ID, with the CVE and any
+// other identifiers in the advisory recorded as aliases.
+func OpenGHSA(r io.Reader, products []string) (*vex.VEX, error) {
+	if len(products) == 0 {
+		return nil, errors.New("ghsa: at least one product is required")
+	}
+
+	advisory := &ghsaAdvisory{}
+	if err := json.NewDecoder(r).Decode(advisory); err != nil {
+		return nil, fmt.Errorf("ghsa: failed to decode advisory: %w", err)
+	}
+	if advisory.GHSAID == "" {
+		return nil, errors.New("ghsa: advisory has no GHSA ID")
+	}
+
+	vuln := vex.Vulnerability{
+		Name:        vex.VulnerabilityID(CanonicalVulnerabilityID(advisory.GHSAID)),
+		Description: advisory.Summary,
+	}
+	if strings.HasPrefix(advisory.HTMLURL, "https://") {
+		vuln.ID = advisory.HTMLURL
+	}
+	aliases := vex.Vulnerability{Name: vex.VulnerabilityID(CanonicalVulnerabilityID(advisory.CVEID))}
+	for _, id := range advisory.Identifiers {
+		aliases.Aliases = append(aliases.Aliases, vex.VulnerabilityID(CanonicalVulnerabilityID(id.Value)))
+	}
+	vuln = mergeAliases(vuln, aliases)
+
+	doc := NewDocument()
+	for _, product := range products {
+		doc.Statements = append(doc.Statements, vex.Statement{
+			Vulnerability: vuln,
+			Products:      []vex.Product{{Component: vex.Component{ID: product}}},
+			Status:        vex.StatusUnderInvestigation,
+		})
+	}
+	if _, err := doc.GenerateCanonicalID(); err != nil {
+		return nil, fmt.Errorf("generating document id: %w", err)
+	}
+	return &doc, nil
+}
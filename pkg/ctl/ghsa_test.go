@@ -0,0 +1,48 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestOpenGHSA(t *testing.T) {
+	products := []string{
+		"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+		"pkg:oci/app@sha256%3A74fa0a1d1aac2ea4f1d4da6b7a1e1b8c4d6f2c5e4b3a2f1e0d9c8b7a6f5e4d3c",
+	}
+	f, err := os.Open("testdata/ghsa/advisory.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	doc, err := OpenGHSA(f, products)
+	require.NoError(t, err)
+	require.NotEmpty(t, doc.ID)
+	require.Len(t, doc.Statements, 2)
+	for i, s := range doc.Statements { //nolint:gocritic // this IS supposed to copy
+		require.Equal(t, vex.StatusUnderInvestigation, s.Status)
+		require.NoError(t, s.Validate())
+		require.Equal(t, products[i], s.Products[0].ID)
+		require.Equal(t, vex.VulnerabilityID("GHSA-jfh8-c2jp-5v3q"), s.Vulnerability.Name)
+		require.Equal(t, []vex.VulnerabilityID{"CVE-2021-44228"}, s.Vulnerability.Aliases)
+		require.Equal(t, "https://github.com/advisories/GHSA-jfh8-c2jp-5v3q", s.Vulnerability.ID)
+		require.Equal(t, "Remote code injection in Log4j", s.Vulnerability.Description)
+	}
+
+	// Statements match both identifiers
+	require.True(t, doc.Statements[0].Vulnerability.Matches("CVE-2021-44228"))
+
+	_, err = OpenGHSA(strings.NewReader(`{"ghsa_id": "GHSA-jfh8-c2jp-5v3q"}`), nil)
+	require.Error(t, err)
+	_, err = OpenGHSA(strings.NewReader(`{"summary": "no id"}`), products)
+	require.Error(t, err)
+}
@@ -357,8 +357,19 @@ type MergeOptions struct {
 	DocumentID      string   // ID to use in the new document
 	Author          string   // Author to use in the new document
 	AuthorRole      string   // Role of the document author
+	Supplier        string   // Supplier of the products, defaults to the one shared by all docs
 	Products        []string // Product IDs to consider
 	Vulnerabilities []string // IDs of vulnerabilities to merge
+
+	// ResolveAlias maps a vulnerability identifier to the identifier of its
+	// canonical record, for example looking up the CVE of a GHSA advisory.
+	// Statements with identifiers resolving to the same one are considered
+	// to describe the same vulnerability. When not set, statements are only
+	// grouped by the aliases they list.
+	ResolveAlias func(string) string
+
+	// Log records the changes made to the merged statements, if set
+	Log *TransformLog
 }
 
 // Merge combines the statements from a number of documents into
@@ -376,21 +387,12 @@ func (impl *defaultVexCtlImplementation) Merge(
 	if docID == "" {
 		ids := []string{}
 		for i, d := range docs {
-			if d.ID == "" {
-				ids = append(ids, fmt.Sprintf("VEX-DOC-%d", i))
-			} else {
-				ids = append(ids, d.ID)
-			}
+			ids = append(ids, mergedDocumentRef(d, i))
 		}
-
-		sort.Strings(ids)
-		h := sha256.New()
-		h.Write([]byte(strings.Join(ids, ":")))
-		// Hash the sorted IDs list
-		docID = fmt.Sprintf("merged-vex-%x", h.Sum(nil))
+		docID = mergedDocumentID(ids)
 	}
 
-	newDoc := vex.New()
+	newDoc := NewDocument()
 
 	newDoc.ID = docID
 	if author := mergeOpts.Author; author != "" {
@@ -399,6 +401,10 @@ func (impl *defaultVexCtlImplementation) Merge(
 	if authorRole := mergeOpts.AuthorRole; authorRole != "" {
 		newDoc.AuthorRole = authorRole
 	}
+	newDoc.Supplier = mergeOpts.Supplier
+	if newDoc.Supplier == "" {
+		newDoc.Supplier = commonSupplier(docs)
+	}
 
 	ss := []vex.Statement{}
 
@@ -447,10 +453,21 @@ func (impl *defaultVexCtlImplementation) Merge(
 				s.Timestamp = doc.Timestamp
 			}
 
+			// When the supplier of the source document is not the one of
+			// the merged document, record it in the products to preserve it.
+			if doc.Supplier != "" && doc.Supplier != newDoc.Supplier {
+				s.Products = productsWithSupplier(s.Products, doc.Supplier)
+			}
+
 			ss = append(ss, s)
 		}
 	}
 
+	// Key the statements about the same vulnerability by the same name
+	// so they are sorted together and their effective status is computed
+	// from all of them.
+	ss = reconcileAliases(ss, mergeOpts.ResolveAlias, mergeOpts.Log)
+
 	vex.SortStatements(ss, *newDoc.Metadata.Timestamp)
 
 	newDoc.Statements = ss
@@ -458,6 +475,114 @@ func (impl *defaultVexCtlImplementation) Merge(
 	return &newDoc, nil
 }
 
+// mergedDocumentRef returns the identifier a merged document is referenced
+// by when computing the ID of the merge, given its position in the input
+func mergedDocumentRef(doc *vex.VEX, i int) string {
+	if doc.ID == "" {
+		return fmt.Sprintf("VEX-DOC-%d", i)
+	}
+	return doc.ID
+}
+
+// mergedDocumentID computes the deterministic ID of a merged document from
+// the hash of the sorted references of the documents merged
+func mergedDocumentID(ids []string) string {
+	sort.Strings(ids)
+	h := sha256.New()
+	h.Write([]byte(strings.Join(ids, ":")))
+	return fmt.Sprintf("merged-vex-%x", h.Sum(nil))
+}
+
+// reconcileAliases groups statements that describe the same vulnerability
+// under different identifiers and renames them to the name of the first
+// statement in each group. The other identifiers are kept as aliases.
+func reconcileAliases(ss []vex.Statement, resolve func(string) string, log *TransformLog) []vex.Statement {
+	if resolve == nil {
+		resolve = func(id string) string { return id }
+	}
+
+	// Union-find of the (resolved) vulnerability identifiers
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(id string) string {
+		if p, ok := parent[id]; ok && p != id {
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		parent[id] = id
+		return id
+	}
+
+	for i := range ss {
+		ids := vulnerabilityIdentifiers(&ss[i].Vulnerability)
+		if len(ids) == 0 {
+			continue
+		}
+		root := find(resolve(ids[0]))
+		for _, id := range ids[1:] {
+			if r := find(resolve(id)); r != root {
+				parent[r] = root
+			}
+		}
+	}
+
+	// The first statement of each group names the vulnerability
+	names := map[string]vex.VulnerabilityID{}
+	ret := make([]vex.Statement, len(ss))
+	for i := range ss {
+		ret[i] = ss[i]
+		ids := vulnerabilityIdentifiers(&ss[i].Vulnerability)
+		if len(ids) == 0 || ss[i].Vulnerability.Name == "" {
+			continue
+		}
+		root := find(resolve(ids[0]))
+		name, ok := names[root]
+		if !ok {
+			names[root] = ss[i].Vulnerability.Name
+			continue
+		}
+		if name == ss[i].Vulnerability.Name {
+			continue
+		}
+		renamed := ss[i].Vulnerability
+		renamed.Name = name
+		renamed.Aliases = nil
+		ret[i].Vulnerability = mergeAliases(renamed, ss[i].Vulnerability)
+		log.add(TransformEntry{
+			Action: TransformRenamedVulnerability, Statement: i,
+			Vulnerability: string(ss[i].Vulnerability.Name),
+			Detail:        fmt.Sprintf("renamed to its alias %s", name),
+		})
+	}
+	return ret
+}
+
+// commonSupplier returns the supplier of a set of documents if all of them
+// share the same one, an empty string otherwise.
+func commonSupplier(docs []*vex.VEX) string {
+	supplier := docs[0].Supplier
+	for _, doc := range docs[1:] {
+		if doc.Supplier != supplier {
+			return ""
+		}
+	}
+	return supplier
+}
+
+// productsWithSupplier returns a copy of a list of products with the supplier
+// set in those that don't have one
+func productsWithSupplier(products []vex.Product, supplier string) []vex.Product {
+	ret := make([]vex.Product, len(products))
+	for i := range products {
+		ret[i] = products[i]
+		if ret[i].Supplier == "" {
+			ret[i].Supplier = supplier
+		}
+	}
+	return ret
+}
+
 // LoadFiles loads multiple vex files from disk
 func (impl *defaultVexCtlImplementation) LoadFiles(
 	_ context.Context, filePaths []string,
@@ -711,7 +836,7 @@ func (impl *defaultVexCtlImplementation) InitTemplatesDir(path string) error {
 	if err != nil {
 		return fmt.Errorf("creating initial openvex document: %w", err)
 	}
-	newDoc := vex.New()
+	newDoc := NewDocument()
 	newDoc.Metadata.Author = "vexctl (automated template)"
 	// TODO(puerco) This should be randomized
 	if _, err := newDoc.GenerateCanonicalID(); err != nil {
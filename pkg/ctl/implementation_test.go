@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/stretchr/testify/require"
@@ -327,6 +328,83 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeSupplier(t *testing.T) {
+	ctx := context.Background()
+	impl := defaultVexCtlImplementation{}
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	doc1 := testDocument(ts, testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts))
+	doc1.Supplier = "Wolfi"
+	doc2 := testDocument(ts, testStatement("CVE-2023-0002", "pkg:apk/wolfi/git@2.0.0", vex.StatusFixed, ts))
+	doc2.Supplier = "Wolfi"
+
+	// A supplier shared by all documents is kept
+	doc, err := impl.Merge(ctx, &MergeOptions{}, []*vex.VEX{doc1, doc2})
+	require.NoError(t, err)
+	require.Equal(t, "Wolfi", doc.Supplier)
+	require.Empty(t, doc.Statements[0].Products[0].Supplier)
+
+	// Differing suppliers are recorded in the products
+	doc2.Supplier = "Chainguard"
+	doc, err = impl.Merge(ctx, &MergeOptions{}, []*vex.VEX{doc1, doc2})
+	require.NoError(t, err)
+	require.Empty(t, doc.Supplier)
+	require.Equal(t, "Wolfi", doc.Statements[0].Products[0].Supplier)
+	require.Equal(t, "Chainguard", doc.Statements[1].Products[0].Supplier)
+	require.Empty(t, doc2.Statements[0].Products[0].Supplier, "source documents must not be modified")
+
+	// The supplier can be set in the options
+	doc, err = impl.Merge(ctx, &MergeOptions{Supplier: "Wolfi"}, []*vex.VEX{doc1, doc2})
+	require.NoError(t, err)
+	require.Equal(t, "Wolfi", doc.Supplier)
+	require.Empty(t, doc.Statements[0].Products[0].Supplier)
+	require.Equal(t, "Chainguard", doc.Statements[1].Products[0].Supplier)
+}
+
+func TestMergeAliases(t *testing.T) {
+	ctx := context.Background()
+	impl := defaultVexCtlImplementation{}
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+
+	cveDoc := testDocument(t1, testStatement("CVE-2023-0001", product, vex.StatusUnderInvestigation, t1))
+	ghsa := testStatement("GHSA-abcd-efgh-ijkl", product, vex.StatusFixed, t2)
+	ghsaDoc := testDocument(t2, ghsa)
+
+	// Without aliases the statements are distinct vulnerabilities
+	doc, err := impl.Merge(ctx, &MergeOptions{}, []*vex.VEX{cveDoc, ghsaDoc})
+	require.NoError(t, err)
+	require.Len(t, EffectiveStatuses([]*vex.VEX{doc}, product), 2)
+
+	for name, tc := range map[string]struct {
+		aliases []vex.VulnerabilityID
+		resolve func(string) string
+	}{
+		"listed aliases": {aliases: []vex.VulnerabilityID{"CVE-2023-0001"}},
+		"resolver": {resolve: func(id string) string {
+			if id == "GHSA-abcd-efgh-ijkl" {
+				return "CVE-2023-0001"
+			}
+			return id
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ghsaDoc.Statements[0].Vulnerability.Aliases = tc.aliases
+			log := &TransformLog{}
+			doc, err := impl.Merge(ctx, &MergeOptions{ResolveAlias: tc.resolve, Log: log}, []*vex.VEX{cveDoc, ghsaDoc})
+			require.NoError(t, err)
+			require.Len(t, doc.Statements, 2)
+			require.Len(t, log.Entries, 1)
+			require.Equal(t, TransformRenamedVulnerability, log.Entries[0].Action)
+			require.Equal(t, map[string]vex.Status{"CVE-2023-0001": vex.StatusFixed}, EffectiveStatuses([]*vex.VEX{doc}, product))
+			require.Equal(t, vex.VulnerabilityID("CVE-2023-0001"), doc.Statements[1].Vulnerability.Name)
+			require.Equal(t, []vex.VulnerabilityID{"GHSA-abcd-efgh-ijkl"}, doc.Statements[1].Vulnerability.Aliases)
+			require.Equal(t, vex.VulnerabilityID("GHSA-abcd-efgh-ijkl"), ghsaDoc.Statements[0].Vulnerability.Name)
+		})
+	}
+}
+
 func TestReadGoldenData(t *testing.T) {
 	sut := defaultVexCtlImplementation{}
 	for _, tc := range []struct {
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// Index provides fast lookups of the statements in a document by any of the
+// identifiers of their vulnerabilities and products. Vulnerabilities are
+// looked up by their canonical identifiers (see CanonicalVulnerabilityID),
+// so lookups of known schemes such as CVE are case insensitive.
+type Index struct {
+	doc             *vex.VEX
+	byVulnerability map[string][]int
+	byProduct       map[string][]int
+}
+
+// NewIndex builds a new index of the statements in a document
+func NewIndex(doc *vex.VEX) *Index {
+	idx := &Index{
+		doc:             doc,
+		byVulnerability: map[string][]int{},
+		byProduct:       map[string][]int{},
+	}
+
+	for i := range doc.Statements {
+		for _, id := range vulnerabilityIdentifiers(&doc.Statements[i].Vulnerability) {
+			id = CanonicalVulnerabilityID(id)
+			idx.byVulnerability[id] = appendIndex(idx.byVulnerability[id], i)
+		}
+		for _, p := range doc.Statements[i].Products {
+			for _, id := range componentIdentifiers(&p.Component) {
+				idx.byProduct[id] = appendIndex(idx.byProduct[id], i)
+			}
+		}
+	}
+	return idx
+}
+
+// StatementsByVulnerability returns the statements about a vulnerability
+func (idx *Index) StatementsByVulnerability(id string) []vex.Statement {
+	return idx.statements(idx.byVulnerability[CanonicalVulnerabilityID(id)])
+}
+
+// StatementsByProduct returns the statements listing a product identifier.
+// Identifiers are compared as strings, purls are not matched more generally.
+func (idx *Index) StatementsByProduct(id string) []vex.Statement {
+	return idx.statements(idx.byProduct[id])
+}
+
+func (idx *Index) statements(positions []int) []vex.Statement {
+	ss := make([]vex.Statement, 0, len(positions))
+	for _, i := range positions {
+		ss = append(ss, idx.doc.Statements[i])
+	}
+	return ss
+}
+
+// appendIndex adds i to the list, skipping it if is already the last one
+func appendIndex(list []int, i int) []int {
+	if len(list) > 0 && list[len(list)-1] == i {
+		return list
+	}
+	return append(list, i)
+}
+
+// componentIdentifiers returns all the strings that identify a component
+func componentIdentifiers(c *vex.Component) []string {
+	ids := []string{}
+	if c.ID != "" {
+		ids = append(ids, c.ID)
+	}
+	for _, id := range c.Identifiers {
+		ids = append(ids, id)
+	}
+	for _, h := range c.Hashes {
+		ids = append(ids, string(h))
+	}
+	return ids
+}
+
+// IndexCache keeps the indexes of documents and only rebuilds them when the
+// documents change. A document is considered changed when its version, last
+// updated date or number of statements are not the same as when its index
+// was built. Callers that modify statements in place without updating the
+// document should call Invalidate. IndexCache is safe for concurrent use.
+type IndexCache struct {
+	mu      sync.Mutex
+	entries map[*vex.VEX]*cachedIndex
+}
+
+type cachedIndex struct {
+	index       *Index
+	version     int
+	lastUpdated time.Time
+	statements  int
+}
+
+// NewIndexCache returns a new, empty index cache
+func NewIndexCache() *IndexCache {
+	return &IndexCache{
+		entries: map[*vex.VEX]*cachedIndex{},
+	}
+}
+
+// Index returns the index of a document, building it if the document has
+// changed since it was last indexed.
+func (cache *IndexCache) Index(doc *vex.VEX) *Index {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	var lastUpdated time.Time
+	if doc.LastUpdated != nil {
+		lastUpdated = *doc.LastUpdated
+	}
+
+	if entry, ok := cache.entries[doc]; ok &&
+		entry.version == doc.Version &&
+		entry.lastUpdated.Equal(lastUpdated) &&
+		entry.statements == len(doc.Statements) {
+		return entry.index
+	}
+
+	entry := &cachedIndex{
+		index:       NewIndex(doc),
+		version:     doc.Version,
+		lastUpdated: lastUpdated,
+		statements:  len(doc.Statements),
+	}
+	cache.entries[doc] = entry
+	return entry.index
+}
+
+// Invalidate drops the cached index of a document
+func (cache *IndexCache) Invalidate(doc *vex.VEX) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	delete(cache.entries, doc)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestIndex(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	s1 := testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)
+	s1.Vulnerability.Aliases = []vex.VulnerabilityID{"GHSA-abcd-efgh-ijkl"}
+	s2 := testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)
+	s2.Products = append(s2.Products, vex.Product{
+		Component: vex.Component{
+			ID:     "pkg:apk/wolfi/git@1.0.0",
+			Hashes: map[vex.Algorithm]vex.Hash{vex.SHA256: "1234"},
+		},
+	})
+
+	idx := NewIndex(testDocument(ts, s1, s2))
+	require.Equal(t, []vex.Statement{s1}, idx.StatementsByVulnerability("CVE-2023-0001"))
+	require.Equal(t, []vex.Statement{s1}, idx.StatementsByVulnerability("GHSA-abcd-efgh-ijkl"))
+	require.Equal(t, []vex.Statement{s1, s2}, idx.StatementsByProduct("pkg:apk/wolfi/bash@1.0.0"))
+	require.Equal(t, []vex.Statement{s2}, idx.StatementsByProduct("1234"))
+	require.Empty(t, idx.StatementsByProduct("pkg:apk/wolfi/curl@1.0.0"))
+}
+
+func TestIndexCache(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(ts, testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts))
+	cache := NewIndexCache()
+
+	idx := cache.Index(doc)
+	require.Len(t, idx.StatementsByProduct("pkg:apk/wolfi/bash@1.0.0"), 1)
+
+	// Without changes, the index is reused
+	require.Same(t, idx, cache.Index(doc))
+
+	// Adding a statement invalidates the cache
+	doc.Statements = append(doc.Statements, testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts))
+	idx2 := cache.Index(doc)
+	require.NotSame(t, idx, idx2)
+	require.Len(t, idx2.StatementsByProduct("pkg:apk/wolfi/bash@1.0.0"), 2)
+
+	// Changing a statement and bumping the document also invalidates it
+	now := ts.Add(time.Hour)
+	doc.Statements[0].Status = vex.StatusAffected
+	doc.LastUpdated = &now
+	doc.Version++
+	idx3 := cache.Index(doc)
+	require.NotSame(t, idx2, idx3)
+	require.Equal(t, vex.StatusAffected, idx3.StatementsByVulnerability("CVE-2023-0001")[0].Status)
+
+	// Invalidating explicitly forces a rebuild
+	cache.Invalidate(doc)
+	require.NotSame(t, idx3, cache.Index(doc))
+}
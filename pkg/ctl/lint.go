@@ -0,0 +1,409 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	purl "github.com/package-url/packageurl-go"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// FindingLevel captures how serious a lint finding is
+type FindingLevel string
+
+const (
+	// LevelError findings make the document invalid
+	LevelError FindingLevel = "error"
+
+	// LevelWarning findings flag problems that don't invalidate the document
+	LevelWarning FindingLevel = "warning"
+)
+
+// Finding is a problem found when linting a VEX document
+type Finding struct {
+	Level   FindingLevel
+	Message string
+	Pointer string // JSON pointer to the problematic value, eg /statements/4/status
+}
+
+// lintRule is a function that checks a document and returns its findings
+type lintRule func(*vex.VEX) []Finding
+
+// lintRules is the list of rules run by Lint
+var lintRules = []lintRule{
+	lintSpecVersion,
+	lintDocumentID,
+	lintStatements,
+	lintFieldConflicts,
+	lintReferences,
+	lintJustifications,
+	chronologyFindings,
+}
+
+// Lint checks a VEX document and returns a list of findings describing any
+// problems found in it. The returned list is empty if the document is valid.
+func Lint(doc *vex.VEX) []Finding {
+	findings := []Finding{}
+	for _, rule := range lintRules {
+		findings = append(findings, rule(doc)...)
+	}
+	return findings
+}
+
+// LintOptions control the optional checks of LintData
+type LintOptions struct {
+	// StrictTimestamps flags the timestamps that are not full RFC 3339
+	// date-times with a time zone, as required by some regulated
+	// environments.
+	StrictTimestamps bool
+}
+
+// strictTimestamp matches an RFC 3339 date-time with its time zone
+var strictTimestamp = regexp.MustCompile(
+	`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`,
+)
+
+// timestampFields are the names of the timestamp fields in documents and
+// statements
+var timestampFields = []string{"timestamp", "last_updated", "action_statement_timestamp"}
+
+// LintData parses a document and lints it, running the checks on the raw
+// data enabled in the options as well as the ones of Lint. Documents failing
+// the raw data checks may not be parseable; in that case only the findings
+// of those checks are returned. Any other parsing error is returned as is.
+// Nil options use the defaults.
+func LintData(data []byte, opts *LintOptions) ([]Finding, error) {
+	if opts == nil {
+		opts = &LintOptions{}
+	}
+	findings := []Finding{}
+	if opts.StrictTimestamps {
+		tsFindings, err := lintTimestamps(data)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, tsFindings...)
+	}
+
+	doc, err := ParseDocument(data)
+	if err != nil {
+		if len(findings) > 0 {
+			return findings, nil
+		}
+		return nil, err
+	}
+	return append(findings, Lint(doc)...), nil
+}
+
+// lintTimestamps flags the timestamps of a document and its statements that
+// are not strict RFC 3339 date-times
+func lintTimestamps(data []byte) ([]Finding, error) {
+	doc := map[string]any{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing document: %w", err)
+	}
+
+	findings := []Finding{}
+	check := func(values map[string]any, prefix string) {
+		for _, field := range timestampFields {
+			v, ok := values[field]
+			if !ok || v == nil {
+				continue
+			}
+			if ts, ok := v.(string); ok && strictTimestamp.MatchString(ts) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Level:   LevelError,
+				Message: fmt.Sprintf("%s %v is not a RFC 3339 date-time with time zone", field, v),
+				Pointer: prefix + "/" + field,
+			})
+		}
+	}
+
+	check(doc, "")
+	statements, _ := doc["statements"].([]any)
+	for i := range statements {
+		if s, ok := statements[i].(map[string]any); ok {
+			check(s, fmt.Sprintf("/statements/%d", i))
+		}
+	}
+	return findings, nil
+}
+
+// lintSpecVersion warns when the document targets an unknown spec version
+func lintSpecVersion(doc *vex.VEX) []Finding {
+	version := SpecVersion(doc)
+	switch {
+	case version == "":
+		return []Finding{{
+			Level:   LevelError,
+			Message: fmt.Sprintf("document context %q is not an OpenVEX context", doc.Context),
+			Pointer: "/@context",
+		}}
+	case !knownSpecVersion(version):
+		return []Finding{{
+			Level: LevelWarning,
+			Message: fmt.Sprintf(
+				"unknown OpenVEX spec version %s (known versions: %s)",
+				version, strings.Join(KnownSpecVersions, ", "),
+			),
+			Pointer: "/@context",
+		}}
+	}
+	return nil
+}
+
+// uriScheme matches the scheme at the start of URI-style identifiers
+var uriScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// lintDocumentID checks that document IDs that look like URIs, as the ones
+// generated by GenerateCanonicalID, are valid URIs. IDs without a scheme
+// are opaque identifiers and are accepted as they are.
+func lintDocumentID(doc *vex.VEX) []Finding {
+	if !uriScheme.MatchString(doc.ID) {
+		return nil
+	}
+	u, err := url.Parse(doc.ID)
+	switch {
+	case err != nil:
+		err = errors.Unwrap(err)
+	case (u.Scheme == "http" || u.Scheme == "https") && u.Host == "":
+		err = errors.New("missing host")
+	default:
+		return nil
+	}
+	return []Finding{{
+		Level:   LevelError,
+		Message: fmt.Sprintf("document ID %q is not a valid URI: %s", doc.ID, err),
+		Pointer: "/@id",
+	}}
+}
+
+// lintStatements runs the statement validation on every statement
+func lintStatements(doc *vex.VEX) []Finding {
+	findings := []Finding{}
+	for i := range doc.Statements {
+		if err := doc.Statements[i].Validate(); err != nil {
+			findings = append(findings, Finding{
+				Level:   LevelError,
+				Message: fmt.Sprintf("statement #%d: %s", i, err),
+				Pointer: fmt.Sprintf("/statements/%d/%s", i, invalidStatementField(&doc.Statements[i])),
+			})
+		}
+	}
+	return findings
+}
+
+// invalidStatementField returns the name of the JSON field that makes a
+// statement fail validation. The checks mirror the order of the ones in
+// vex.Statement.Validate so the field matches the error it returns.
+func invalidStatementField(s *vex.Statement) string {
+	if !s.Status.Valid() {
+		return "status"
+	}
+
+	if s.Status == vex.StatusNotAffected {
+		if s.Justification == "" && s.ImpactStatement == "" {
+			return "justification"
+		}
+		if s.Justification != "" && !s.Justification.Valid() {
+			return "justification"
+		}
+		return "action_statement"
+	}
+
+	switch {
+	case s.Justification != "":
+		return "justification"
+	case s.ImpactStatement != "":
+		return "impact_statement"
+	}
+	return "action_statement"
+}
+
+// fieldConflict is a combination of statement fields that contradict each
+// other. Conflicts listing statuses only apply to statements with one of
+// them. The message is formatted with the status of the statement.
+type fieldConflict struct {
+	statuses []vex.Status
+	field    string
+	level    FindingLevel
+	message  string
+	set      func(*vex.Statement) bool
+}
+
+func hasJustification(s *vex.Statement) bool   { return s.Justification != "" }
+func hasImpactStatement(s *vex.Statement) bool { return s.ImpactStatement != "" }
+func hasActionStatement(s *vex.Statement) bool { return s.ActionStatement != "" }
+
+// fieldConflicts are the cross field rules checked by lintFieldConflicts
+var fieldConflicts = []fieldConflict{
+	// A not_affected statement requires no action, an action statement
+	// would tell consumers to remediate a product that is not vulnerable.
+	{
+		[]vex.Status{vex.StatusNotAffected}, "action_statement", LevelError,
+		"action statement on a %s statement, which requires no action", hasActionStatement,
+	},
+
+	// Justifications and impact statements explain why a product is not
+	// affected, they contradict any other status.
+	{
+		[]vex.Status{vex.StatusAffected, vex.StatusFixed, vex.StatusUnderInvestigation}, "justification", LevelError,
+		"justification on a %s statement, only not_affected statements are justified", hasJustification,
+	},
+	{
+		[]vex.Status{vex.StatusAffected, vex.StatusFixed, vex.StatusUnderInvestigation}, "impact_statement", LevelError,
+		"impact statement on a %s statement, only not_affected statements explain their impact", hasImpactStatement,
+	},
+
+	// Fixed products and those under investigation have nothing to act on
+	// yet or anymore, the only actionable status is affected.
+	{
+		[]vex.Status{vex.StatusFixed, vex.StatusUnderInvestigation}, "action_statement", LevelError,
+		"action statement on a %s statement, only affected statements require action", hasActionStatement,
+	},
+
+	// The action statement timestamp dates the action statement, it means
+	// nothing without one. The document is still valid so it is a warning.
+	{
+		nil, "action_statement_timestamp", LevelWarning,
+		"%s statement has an action statement timestamp but no action statement",
+		func(s *vex.Statement) bool { return s.ActionStatementTimestamp != nil && s.ActionStatement == "" },
+	},
+}
+
+// applies returns true if the conflict is present in a statement
+func (c *fieldConflict) applies(s *vex.Statement) bool {
+	if c.statuses != nil && !slices.Contains(c.statuses, s.Status) {
+		return false
+	}
+	return c.set(s)
+}
+
+// lintFieldConflicts reports the fields of the statements that contradict
+// their status or other fields (see fieldConflicts). Statement validation
+// stops at the first problem, this rule lists all of them. The field already
+// reported by lintStatements is skipped to not flag it twice.
+func lintFieldConflicts(doc *vex.VEX) []Finding {
+	findings := []Finding{}
+	for i := range doc.Statements {
+		s := &doc.Statements[i]
+		reported := ""
+		if s.Validate() != nil {
+			reported = invalidStatementField(s)
+		}
+		for j := range fieldConflicts {
+			c := &fieldConflicts[j]
+			if c.field == reported || !c.applies(s) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Level:   c.level,
+				Message: fmt.Sprintf("statement #%d: "+c.message, i, s.Status),
+				Pointer: fmt.Sprintf("/statements/%d/%s", i, c.field),
+			})
+		}
+	}
+	return findings
+}
+
+// lintJustifications warns about not_affected statements that explain why
+// only in free form text. These are valid but cannot be audited by tools.
+func lintJustifications(doc *vex.VEX) []Finding {
+	findings := []Finding{}
+	for i := range doc.Statements {
+		s := &doc.Statements[i]
+		if s.Status != vex.StatusNotAffected || s.Justification != "" || s.ImpactStatement == "" {
+			continue
+		}
+		findings = append(findings, Finding{
+			Level:   LevelWarning,
+			Message: fmt.Sprintf("statement #%d: not_affected statement has no justification", i),
+			Pointer: fmt.Sprintf("/statements/%d/justification", i),
+		})
+	}
+	return findings
+}
+
+// lintReferences cross checks the product and subcomponent references in a
+// document. It reports components without any identifiers and components
+// that are referenced in different statements using equivalent but
+// differently formatted identifiers.
+func lintReferences(doc *vex.VEX) []Finding {
+	findings := []Finding{}
+
+	// seen records where each normalized identifier was first referenced
+	type reference struct{ id, pointer string }
+	seen := map[string]reference{}
+	check := func(id, pointer string) {
+		key := normalizeReference(id)
+		ref, ok := seen[key]
+		if !ok {
+			seen[key] = reference{id, pointer}
+			return
+		}
+		if ref.id != id {
+			findings = append(findings, Finding{
+				Level:   LevelWarning,
+				Message: fmt.Sprintf("%q is referenced as %q in %s", id, ref.id, ref.pointer),
+				Pointer: pointer,
+			})
+		}
+	}
+
+	for i := range doc.Statements {
+		for j := range doc.Statements[i].Products {
+			product := &doc.Statements[i].Products[j]
+			pointer := fmt.Sprintf("/statements/%d/products/%d", i, j)
+			ids := componentIdentifiers(&product.Component)
+			if len(ids) == 0 {
+				msg := "product has no identifiers"
+				if len(product.Subcomponents) > 0 {
+					msg = "subcomponents are not tied to an identified product"
+				}
+				findings = append(findings, Finding{Level: LevelError, Message: msg, Pointer: pointer})
+			}
+			if product.ID != "" {
+				check(product.ID, pointer+"/@id")
+			}
+
+			for k := range product.Subcomponents {
+				sub := &product.Subcomponents[k].Component
+				subPointer := fmt.Sprintf("%s/subcomponents/%d", pointer, k)
+				if len(componentIdentifiers(sub)) == 0 {
+					findings = append(findings, Finding{
+						Level: LevelError, Message: "subcomponent has no identifiers", Pointer: subPointer,
+					})
+				}
+				if sub.ID != "" {
+					check(sub.ID, subPointer+"/@id")
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// normalizeReference returns the canonical form of a component identifier.
+// Purls are parsed to sort their qualifiers and normalize their encoding.
+func normalizeReference(id string) string {
+	id = strings.TrimSpace(id)
+	if strings.HasPrefix(id, "pkg:") {
+		if p, err := purl.FromString(id); err == nil {
+			id = p.ToString()
+		}
+	}
+	return strings.ToLower(id)
+}
@@ -0,0 +1,370 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestSpecVersion(t *testing.T) {
+	for _, tc := range []struct {
+		context  string
+		expected string
+	}{
+		{"https://openvex.dev/ns/v0.2.0", "0.2.0"},
+		{"https://openvex.dev/ns/v0.0.1", "0.0.1"},
+		{"https://openvex.dev/ns", LegacySpecVersion},
+		{"https://openvex.dev/ns/v9.1.0", "9.1.0"},
+		{"https://example.com/ns", ""},
+	} {
+		doc := vex.New()
+		doc.Context = tc.context
+		require.Equal(t, tc.expected, SpecVersion(&doc), tc.context)
+	}
+}
+
+func TestLoadSpecVersions(t *testing.T) {
+	// Documents with and without a version in their context
+	for _, path := range []string{"testdata/v020-1.vex.json", "testdata/v001-1.vex.json"} {
+		doc, err := vex.Open(path)
+		require.NoError(t, err)
+		require.Equal(t, vex.SpecVersion, SpecVersion(doc), path)
+	}
+
+	// Future versions are read with the current parser
+	doc, err := ParseDocument([]byte(`{
+		"@context": "https://openvex.dev/ns/v9.0.0",
+		"@id": "test-doc",
+		"author": "John Doe",
+		"statements": [{
+			"vulnerability": {"name": "CVE-1234-5678"},
+			"products": [{"@id": "pkg:apk/wolfi/bash@1.0.0"}],
+			"status": "fixed"
+		}]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "9.0.0", SpecVersion(doc))
+	require.Len(t, doc.Statements, 1)
+}
+
+func TestLint(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		prepare  func(*vex.VEX)
+		expected []FindingLevel
+		pointers []string
+	}{
+		{"valid doc", func(*vex.VEX) {}, []FindingLevel{}, []string{}},
+		{
+			"unknown spec version",
+			func(doc *vex.VEX) { doc.Context = "https://openvex.dev/ns/v9.0.0" },
+			[]FindingLevel{LevelWarning},
+			[]string{"/@context"},
+		},
+		{
+			"not openvex",
+			func(doc *vex.VEX) { doc.Context = "https://example.com/" },
+			[]FindingLevel{LevelError},
+			[]string{"/@context"},
+		},
+		{
+			"uri id",
+			func(doc *vex.VEX) { doc.ID = "https://openvex.dev/docs/public/vex-1234" },
+			[]FindingLevel{},
+			[]string{},
+		},
+		{
+			"opaque id",
+			func(doc *vex.VEX) { doc.ID = "2022-EVD-UC-01-NA-001" },
+			[]FindingLevel{},
+			[]string{},
+		},
+		{
+			"malformed uri id",
+			func(doc *vex.VEX) { doc.ID = "https://openvex.dev/docs/%zz" },
+			[]FindingLevel{LevelError},
+			[]string{"/@id"},
+		},
+		{
+			"uri id without host",
+			func(doc *vex.VEX) { doc.ID = "https:///vex-1234" },
+			[]FindingLevel{LevelError},
+			[]string{"/@id"},
+		},
+		{
+			"invalid statement",
+			func(doc *vex.VEX) {
+				doc.Statements = append(doc.Statements, testStatement("CVE-1234-5678", "pkg:apk/wolfi/bash@1.0.0", "cheese", ts))
+			},
+			[]FindingLevel{LevelError},
+			[]string{"/statements/1/status"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := vex.New()
+			doc.Statements = []vex.Statement{
+				testStatement("CVE-1234-5678", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+			}
+			tc.prepare(&doc)
+			findings := Lint(&doc)
+			levels := []FindingLevel{}
+			pointers := []string{}
+			for _, f := range findings {
+				levels = append(levels, f.Level)
+				pointers = append(pointers, f.Pointer)
+			}
+			require.Equal(t, tc.expected, levels)
+			require.Equal(t, tc.pointers, pointers)
+		})
+	}
+}
+
+func TestLintStrictTimestamps(t *testing.T) {
+	document := func(docTimestamp, statementTimestamp string) []byte {
+		return []byte(fmt.Sprintf(`{
+			"@context": "https://openvex.dev/ns/v0.2.0",
+			"@id": "test-doc",
+			"author": "John Doe",
+			"timestamp": %q,
+			"version": 1,
+			"statements": [
+				{
+					"vulnerability": {"name": "CVE-2023-0001"},
+					"products": [{"@id": "pkg:apk/wolfi/bash@1.0.0"}],
+					"status": "fixed",
+					"timestamp": %q
+				}
+			]
+		}`, docTimestamp, statementTimestamp))
+	}
+
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		pointers []string
+	}{
+		{"utc", document("2023-01-02T00:00:00Z", "2023-01-01T10:00:00.123Z"), []string{}},
+		{"offset", document("2023-01-02T00:00:00+02:00", "2023-01-01T00:00:00-05:00"), []string{}},
+		{"missing zone", document("2023-01-01T00:00:00Z", "2023-01-01T00:00:00"), []string{"/statements/0/timestamp"}},
+		{"date only", document("2023-01-01", "2023-01-01T00:00:00Z"), []string{"/timestamp"}},
+		{"lowercase", document("2023-01-01t00:00:00z", "2023-01-01 00:00:00Z"), []string{"/timestamp", "/statements/0/timestamp"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := LintData(tc.data, &LintOptions{StrictTimestamps: true})
+			require.NoError(t, err)
+			pointers := []string{}
+			for _, f := range findings {
+				require.Equal(t, LevelError, f.Level)
+				pointers = append(pointers, f.Pointer)
+			}
+			require.Equal(t, tc.pointers, pointers)
+		})
+	}
+
+	// Without the option, unparseable timestamps fail to load
+	_, err := LintData(document("2023-01-01", "2023-01-01T00:00:00Z"), &LintOptions{})
+	require.Error(t, err)
+	_, err = LintData(document("2023-01-01", "2023-01-01T00:00:00Z"), nil)
+	require.Error(t, err)
+}
+
+func TestLintStatementPointers(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		status   vex.Status
+		prepare  func(*vex.Statement)
+		expected string
+	}{
+		{"invalid status", "cheese", func(*vex.Statement) {}, "status"},
+		{
+			"not affected without justification", vex.StatusNotAffected,
+			func(s *vex.Statement) { s.Justification = "" }, "justification",
+		},
+		{
+			"invalid justification", vex.StatusNotAffected,
+			func(s *vex.Statement) { s.Justification = "cheese" }, "justification",
+		},
+		{
+			"not affected with action", vex.StatusNotAffected,
+			func(s *vex.Statement) { s.ActionStatement = "Upgrade" }, "action_statement",
+		},
+		{
+			"affected without action", vex.StatusAffected,
+			func(s *vex.Statement) { s.ActionStatement = "" }, "action_statement",
+		},
+		{
+			"affected with justification", vex.StatusAffected,
+			func(s *vex.Statement) { s.Justification = vex.ComponentNotPresent }, "justification",
+		},
+		{
+			"fixed with impact statement", vex.StatusFixed,
+			func(s *vex.Statement) { s.ImpactStatement = "Not used" }, "impact_statement",
+		},
+		{
+			"under investigation with action", vex.StatusUnderInvestigation,
+			func(s *vex.Statement) { s.ActionStatement = "Upgrade" }, "action_statement",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := vex.New()
+			doc.Statements = []vex.Statement{
+				testStatement("CVE-1234-5678", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+				testStatement("CVE-1234-5678", "pkg:apk/wolfi/bash@1.0.0", tc.status, ts),
+			}
+			tc.prepare(&doc.Statements[1])
+			findings := Lint(&doc)
+			require.Len(t, findings, 1)
+			require.Equal(t, "/statements/1/"+tc.expected, findings[0].Pointer)
+		})
+	}
+}
+
+func TestLintFieldConflicts(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		status   vex.Status
+		prepare  func(*vex.Statement)
+		expected []string
+		level    FindingLevel
+	}{
+		{
+			"not affected with justification and action", vex.StatusNotAffected,
+			func(s *vex.Statement) { s.ActionStatement = "Upgrade" },
+			[]string{"action_statement"}, LevelError,
+		},
+		{
+			"affected with impact statement", vex.StatusAffected,
+			func(s *vex.Statement) { s.ImpactStatement = "Not used" },
+			[]string{"impact_statement"}, LevelError,
+		},
+		{
+			"affected with justification and impact statement", vex.StatusAffected,
+			func(s *vex.Statement) {
+				s.Justification = vex.ComponentNotPresent
+				s.ImpactStatement = "Not used"
+			},
+			[]string{"justification", "impact_statement"}, LevelError,
+		},
+		{
+			"fixed with every other field", vex.StatusFixed,
+			func(s *vex.Statement) {
+				s.Justification = vex.ComponentNotPresent
+				s.ImpactStatement = "Not used"
+				s.ActionStatement = "Upgrade"
+			},
+			[]string{"justification", "impact_statement", "action_statement"}, LevelError,
+		},
+		{
+			"action timestamp without action", vex.StatusFixed,
+			func(s *vex.Statement) { s.ActionStatementTimestamp = &ts },
+			[]string{"action_statement_timestamp"}, LevelWarning,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := vex.New()
+			doc.Statements = []vex.Statement{
+				testStatement("CVE-1234-5678", "pkg:apk/wolfi/bash@1.0.0", tc.status, ts),
+			}
+			tc.prepare(&doc.Statements[0])
+			findings := Lint(&doc)
+			pointers := []string{}
+			for _, f := range findings {
+				pointers = append(pointers, strings.TrimPrefix(f.Pointer, "/statements/0/"))
+			}
+			require.Equal(t, tc.expected, pointers)
+			require.Equal(t, tc.level, findings[len(findings)-1].Level)
+			require.Contains(t, findings[len(findings)-1].Message, "statement #0: ")
+		})
+	}
+
+	// Conflicts not reported by the validation have clear messages
+	doc := vex.New()
+	doc.Statements = []vex.Statement{testStatement("CVE-1234-5678", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)}
+	doc.Statements[0].ImpactStatement = "Not used"
+	doc.Statements[0].ActionStatement = "Upgrade"
+	findings := lintFieldConflicts(&doc)
+	require.Len(t, findings, 1)
+	require.Equal(t, "statement #0: action statement on a fixed statement, only affected statements require action", findings[0].Message)
+}
+
+func TestLintReferences(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		products [][]vex.Product
+		pointers []string
+	}{
+		{
+			"consistent references",
+			[][]vex.Product{
+				{{Component: vex.Component{ID: "pkg:oci/app@sha256%3Aabcd?arch=amd64&tag=1"}}},
+				{{Component: vex.Component{ID: "pkg:oci/app@sha256%3Aabcd?arch=amd64&tag=1"}}},
+			},
+			[]string{},
+		},
+		{
+			"reordered purl qualifiers",
+			[][]vex.Product{
+				{{Component: vex.Component{ID: "pkg:oci/app@sha256%3Aabcd?arch=amd64&tag=1"}}},
+				{{Component: vex.Component{ID: "pkg:oci/app@sha256:abcd?tag=1&arch=amd64"}}},
+			},
+			[]string{"/statements/1/products/0/@id"},
+		},
+		{
+			"different case",
+			[][]vex.Product{
+				{{Component: vex.Component{ID: "https://example.com/app"}}},
+				{
+					{
+						Component: vex.Component{ID: "pkg:oci/app"},
+						Subcomponents: []vex.Subcomponent{
+							{Component: vex.Component{ID: "https://example.com/App"}},
+						},
+					},
+				},
+			},
+			[]string{"/statements/1/products/0/subcomponents/0/@id"},
+		},
+		{
+			"subcomponents without product",
+			[][]vex.Product{
+				{
+					{
+						Subcomponents: []vex.Subcomponent{
+							{Component: vex.Component{ID: "pkg:apk/wolfi/bash@1.0.0"}},
+							{},
+						},
+					},
+				},
+			},
+			[]string{"/statements/0/products/0", "/statements/0/products/0/subcomponents/1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := testDocument(ts)
+			for _, products := range tc.products {
+				s := testStatement("CVE-1234-5678", "", vex.StatusFixed, ts)
+				s.Products = products
+				doc.Statements = append(doc.Statements, s)
+			}
+			pointers := []string{}
+			for _, f := range lintReferences(doc) {
+				pointers = append(pointers, f.Pointer)
+			}
+			require.Equal(t, tc.pointers, pointers)
+		})
+	}
+}
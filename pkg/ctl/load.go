@@ -0,0 +1,584 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+	goyaml "sigs.k8s.io/yaml/goyaml.v3"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+const (
+	// DefaultURLTimeout is the time OpenURL waits for a document to download
+	DefaultURLTimeout = 30 * time.Second
+
+	// DefaultURLMaxSize is the maximum size of a document fetched by OpenURL
+	DefaultURLMaxSize int64 = 10 * 1024 * 1024
+)
+
+// URLOptions control how documents are fetched from the network
+type URLOptions struct {
+	Client      *http.Client  // HTTP client used to fetch, defaults to http.DefaultClient
+	Timeout     time.Duration // Time to wait for the document, defaults to DefaultURLTimeout
+	MaxSize     int64         // Max document size in bytes, defaults to DefaultURLMaxSize
+	RejectEmpty bool          // Return ErrEmptyDocument if the document has no statements
+}
+
+// LoadOptions control how documents are read from disk
+type LoadOptions struct {
+	// Workers is the number of files parsed concurrently. When set
+	// to 1 or less, files are parsed serially.
+	Workers int
+
+	// RejectEmpty makes loading fail with ErrEmptyDocument when a file
+	// has no statements. Empty documents are accepted by default.
+	RejectEmpty bool
+
+	// NoProducts sets how statements without products are handled. By
+	// default they are kept as they are, which makes them match no product.
+	NoProducts NoProductsBehavior
+}
+
+// ErrEmptyDocument is returned by the loaders set to reject documents
+// without statements, which often come from truncated files or from
+// loading the wrong file.
+var ErrEmptyDocument = errors.New("document has no statements")
+
+// IsEmpty returns true if a document has no statements
+func IsEmpty(doc *vex.VEX) bool {
+	return doc == nil || len(doc.Statements) == 0
+}
+
+// NoProductsBehavior defines how statements without products are handled
+type NoProductsBehavior string
+
+const (
+	// NoProductsKeep keeps statements without products unchanged. As they
+	// list no products, they don't apply to any. This is the default.
+	NoProductsKeep NoProductsBehavior = ""
+
+	// NoProductsApplyToAll makes statements without products apply to all
+	// the products listed in other statements of the same document.
+	NoProductsApplyToAll NoProductsBehavior = "apply-to-all"
+
+	// NoProductsDrop removes the statements without products
+	NoProductsDrop NoProductsBehavior = "drop"
+
+	// NoProductsError rejects documents with statements without products,
+	// returning ErrNoProducts
+	NoProductsError NoProductsBehavior = "error"
+)
+
+// ErrNoProducts is returned when rejecting documents with statements that
+// have no products
+var ErrNoProducts = errors.New("statement has no products")
+
+// HandleNoProducts applies a NoProductsBehavior to the statements without
+// products in a document, modifying it in place
+func HandleNoProducts(doc *vex.VEX, behavior NoProductsBehavior) error {
+	switch behavior {
+	case NoProductsKeep:
+		return nil
+	case NoProductsApplyToAll, NoProductsDrop, NoProductsError:
+	default:
+		return fmt.Errorf("unknown behavior for statements without products: %q", behavior)
+	}
+
+	var products []vex.Product
+	if behavior == NoProductsApplyToAll {
+		products = uniqueProducts(doc)
+	}
+
+	statements := make([]vex.Statement, 0, len(doc.Statements))
+	for i := range doc.Statements {
+		s := doc.Statements[i]
+		if len(s.Products) == 0 {
+			switch behavior {
+			case NoProductsError:
+				return fmt.Errorf("statement #%d: %w", i, ErrNoProducts)
+			case NoProductsDrop:
+				continue
+			case NoProductsApplyToAll:
+				s.Products = append([]vex.Product{}, products...)
+			}
+		}
+		statements = append(statements, s)
+	}
+	doc.Statements = statements
+	return nil
+}
+
+// LegacySpecVersion is the version assumed for documents with an
+// unversioned OpenVEX context.
+const LegacySpecVersion = "0.0.1"
+
+// KnownSpecVersions lists the OpenVEX specification versions vexctl can read
+var KnownSpecVersions = []string{LegacySpecVersion, vex.SpecVersion}
+
+// SpecVersion returns the OpenVEX specification version a document targets
+// as expressed in its context locator. Documents with the bare OpenVEX
+// context are assumed to be LegacySpecVersion. If the context is not an
+// OpenVEX locator, SpecVersion returns an empty string.
+func SpecVersion(doc *vex.VEX) string {
+	return specVersionFromContext(doc.Context)
+}
+
+// specVersionFromContext extracts the spec version from a context locator
+func specVersionFromContext(docContext string) string {
+	if !strings.HasPrefix(docContext, vex.Context) {
+		return ""
+	}
+	version := strings.TrimPrefix(strings.TrimPrefix(docContext, vex.Context), "/")
+	if version == "" {
+		return LegacySpecVersion
+	}
+	return strings.TrimPrefix(version, "v")
+}
+
+// knownSpecVersion returns true if vexctl knows how to read a spec version
+func knownSpecVersion(version string) bool {
+	for _, v := range KnownSpecVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOptions control how VEX data is parsed
+type ParseOptions struct {
+	// Warn, when set, is called with the recoverable problems found while
+	// parsing, such as unknown spec versions and the problems found
+	// importing CSAF documents (see CSAFOptions).
+	Warn func(Warning)
+}
+
+// ParseDocument parses VEX data autodetecting its format. The data can be
+// an OpenVEX document in any of its versions or a CSAF document.
+//
+// OpenVEX documents targeting a spec version newer than the ones known to
+// vexctl are parsed as if they were in the current version to allow reading
+// forward-compatible documents.
+func ParseDocument(data []byte) (*vex.VEX, error) {
+	return ParseDocumentWithOptions(data, &ParseOptions{})
+}
+
+// ParseDocumentWithOptions parses VEX data like ParseDocument, reporting the
+// recoverable problems found to the Warn callback in the options. Nil
+// options use the defaults.
+func ParseDocumentWithOptions(data []byte, opts *ParseOptions) (*vex.VEX, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	docContext := struct {
+		Context string `json:"@context"`
+	}{}
+	if err := json.Unmarshal(data, &docContext); err != nil {
+		return nil, fmt.Errorf("parsing document context: %w", err)
+	}
+
+	version := specVersionFromContext(docContext.Context)
+	switch {
+	case version == vex.SpecVersion:
+		// Documents in the current version can be parsed directly
+		return vex.Parse(data)
+	case version != "" && !knownSpecVersion(version):
+		warnFunc(opts.Warn)(WarningUnknownSpecVersion, fmt.Sprintf(
+			"OpenVEX spec version %s is unknown, parsing as %s", version, vex.SpecVersion,
+		))
+		return vex.Parse(data)
+	case version == "" && !bytes.Contains(data, []byte(`"csaf_version"`)):
+		return nil, errors.New("unable to detect document format")
+	case version == "":
+		doc, _, err := parseCSAF(data, &CSAFOptions{Warn: opts.Warn})
+		return doc, err
+	}
+
+	// Legacy documents are only supported from files by the go-vex
+	// library, so we write the data to a temporary file to open it.
+	tmp, err := os.CreateTemp("", "vexctl-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("writing temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("closing temporary file: %w", err)
+	}
+
+	return vex.Open(tmp.Name())
+}
+
+// Migrate parses a VEX document written in an older OpenVEX spec version,
+// or in CSAF, and upgrades it to the current spec. Besides transcoding the
+// document structure, Migrate fills in the data required by the current
+// spec that older documents may lack:
+//
+//   - The context is set to the current spec version.
+//   - Documents without a timestamp get the one of their earliest statement
+//     and, if a later statement exists, are marked last updated at its date
+//     so the document is chronologically consistent (see VerifyChronology).
+//   - Empty authors and roles are set to the defaults (see FillDefaults).
+//   - Unversioned documents are set to version 1.
+//
+// Documents targeting a newer spec than the current one are not downgraded.
+func Migrate(data []byte) (*vex.VEX, error) {
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing document to migrate: %w", err)
+	}
+
+	if version := SpecVersion(doc); version == "" || knownSpecVersion(version) {
+		doc.Context = vex.ContextLocator()
+	}
+
+	if doc.Timestamp == nil || doc.Timestamp.IsZero() {
+		var earliest, latest *time.Time
+		for i := range doc.Statements {
+			ts := doc.Statements[i].Timestamp
+			if ts == nil {
+				continue
+			}
+			if earliest == nil || ts.Before(*earliest) {
+				earliest = ts
+			}
+			if latest == nil || ts.After(*latest) {
+				latest = ts
+			}
+		}
+		if earliest != nil {
+			t := *earliest
+			doc.Timestamp = &t
+		}
+		if latest != nil && doc.LastUpdated == nil && latest.After(*earliest) {
+			t := *latest
+			doc.LastUpdated = &t
+		}
+	}
+
+	FillDefaults(doc)
+
+	if doc.Version == 0 {
+		doc.Version = 1
+	}
+
+	return doc, nil
+}
+
+// OpenURL fetches a VEX document from an HTTP(S) URL using the default options
+func OpenURL(documentURL string) (*vex.VEX, error) {
+	return OpenURLWithOptions(context.Background(), documentURL, &URLOptions{})
+}
+
+// OpenURLWithOptions fetches a VEX document over HTTP(S) and parses it
+// autodetecting its format. Redirects are followed and any response other
+// than 200 OK is returned as an error. Nil options use the defaults.
+func OpenURLWithOptions(ctx context.Context, documentURL string, opts *URLOptions) (*vex.VEX, error) {
+	if opts == nil {
+		opts = &URLOptions{}
+	}
+	u, err := url.Parse(documentURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultURLTimeout
+	}
+
+	maxSize := opts.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultURLMaxSize
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching document: HTTP error %d (%s)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	// Read one byte more than the limit to detect oversized documents
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading document: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("document exceeds the maximum size of %d bytes", maxSize)
+	}
+
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing document from %s: %w", documentURL, err)
+	}
+	if opts.RejectEmpty && IsEmpty(doc) {
+		return nil, fmt.Errorf("%s: %w", documentURL, ErrEmptyDocument)
+	}
+	return doc, nil
+}
+
+// LoadDir reads all the JSON documents in a directory. Documents are returned
+// in the order of their filenames, regardless of how many workers are used.
+// When files fail to parse, all the errors are returned joined. Nil options
+// use the defaults.
+func LoadDir(dir string, opts *LoadOptions) ([]*vex.VEX, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory: %w", err)
+	}
+
+	paths := []string{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, e.Name()))
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Each worker writes only to the index it is working on, so the
+	// results and errors don't need any further synchronization.
+	docs := make([]*vex.VEX, len(paths))
+	errs := make([]error, len(paths))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				doc, err := vex.Open(paths[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("%s: %w", paths[i], err)
+					continue
+				}
+				if opts.RejectEmpty && IsEmpty(doc) {
+					errs[i] = fmt.Errorf("%s: %w", paths[i], ErrEmptyDocument)
+					continue
+				}
+				if err := HandleNoProducts(doc, opts.NoProducts); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", paths[i], err)
+					continue
+				}
+				docs[i] = doc
+			}
+		}()
+	}
+
+	for i := range paths {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("loading documents: %w", err)
+	}
+	return docs, nil
+}
+
+// StatementError captures a statement that failed validation when loading
+type StatementError struct {
+	Index     int           // Position of the statement in the original document
+	Statement vex.Statement // The invalid statement
+	Err       error         // The validation error
+}
+
+func (se *StatementError) Error() string {
+	return fmt.Sprintf("statement #%d: %s", se.Index, se.Err)
+}
+
+func (se *StatementError) Unwrap() error {
+	return se.Err
+}
+
+// ParseLenient parses VEX data and validates each of its statements. Instead
+// of failing when a statement is invalid, it returns a document with the
+// statements that passed validation and a list of the ones that did not.
+// An error is only returned when the data cannot be parsed at all.
+func ParseLenient(data []byte) (*vex.VEX, []*StatementError, error) {
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	problems := []*StatementError{}
+	valid := []vex.Statement{}
+	for i := range doc.Statements {
+		if err := doc.Statements[i].Validate(); err != nil {
+			problems = append(problems, &StatementError{
+				Index: i, Statement: doc.Statements[i], Err: err,
+			})
+			continue
+		}
+		valid = append(valid, doc.Statements[i])
+	}
+	doc.Statements = valid
+	return doc, problems, nil
+}
+
+// OpenTar reads the VEX documents in a tar archive, which may be gzipped.
+// Each file in the archive is parsed detecting its format from its
+// contents, documents can be JSON or YAML. Entries that are not VEX
+// documents are skipped. The documents are returned sorted by date.
+func OpenTar(r io.Reader) ([]*vex.VEX, error) {
+	br := bufio.NewReader(r)
+
+	// Check for the gzip magic bytes to see if the archive is compressed
+	var tarStream io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("opening gzip stream: %w", err)
+		}
+		defer gz.Close()
+		tarStream = gz
+	}
+
+	docs := []*vex.VEX{}
+	tr := tar.NewReader(tarStream)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tar archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from archive: %w", hdr.Name, err)
+		}
+
+		doc, err := parseDocumentContent(data)
+		if err != nil {
+			logrus.Debugf("skipping %s: %v", hdr.Name, err)
+			continue
+		}
+		docs = append(docs, doc)
+	}
+
+	return vex.SortDocuments(docs), nil
+}
+
+// parseDocumentContent parses a VEX document in JSON or YAML
+func parseDocumentContent(data []byte) (*vex.VEX, error) {
+	if !json.Valid(data) {
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("document is neither JSON nor YAML: %w", err)
+		}
+		data = jsonData
+	}
+	return ParseDocument(data)
+}
+
+// OpenYAMLMulti opens a YAML file holding any number of VEX documents
+// separated by "---" and returns all of them in the order they appear.
+// Empty documents in the stream are skipped. Documents that fail to parse
+// don't stop the others from loading: the documents that could be read are
+// returned along with the errors joined. A malformed YAML stream cannot be
+// read past the error, so only the documents before it are returned.
+func OpenYAMLMulti(path string) ([]*vex.VEX, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening YAML file: %w", err)
+	}
+	defer f.Close()
+
+	docs := []*vex.VEX{}
+	errs := []error{}
+	dec := goyaml.NewDecoder(f)
+	for i := 0; ; i++ {
+		var node goyaml.Node
+		if err := dec.Decode(&node); err != nil {
+			if !errors.Is(err, io.EOF) {
+				errs = append(errs, fmt.Errorf("decoding YAML document #%d: %w", i, err))
+			}
+			break
+		}
+		if len(node.Content) == 0 || node.Content[0].Tag == "!!null" {
+			continue
+		}
+
+		doc, err := parseYAMLNode(&node)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("YAML document #%d: %w", i, err))
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs, errors.Join(errs...)
+}
+
+// parseYAMLNode parses a VEX document from a decoded YAML document
+func parseYAMLNode(node *goyaml.Node) (*vex.VEX, error) {
+	data, err := goyaml.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("encoding YAML document: %w", err)
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("converting YAML document: %w", err)
+	}
+	return ParseDocument(jsonData)
+}
@@ -0,0 +1,416 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestParseDocument(t *testing.T) {
+	for _, tc := range []struct {
+		path    string
+		lenStmt int
+		mustErr bool
+	}{
+		{"testdata/v020-1.vex.json", 1, false},
+		{"testdata/v001-1.vex.json", 1, false},
+		{"testdata/nginx.sarif.json", 0, true},
+	} {
+		data, err := os.ReadFile(tc.path)
+		require.NoError(t, err)
+		doc, err := ParseDocument(data)
+		if tc.mustErr {
+			require.Error(t, err, tc.path)
+			continue
+		}
+		require.NoError(t, err, tc.path)
+		require.Len(t, doc.Statements, tc.lenStmt, tc.path)
+	}
+}
+
+func TestParseDocumentWithOptions(t *testing.T) {
+	warnings := []Warning{}
+	opts := &ParseOptions{Warn: func(w Warning) { warnings = append(warnings, w) }}
+
+	// Unknown spec versions are parsed as the current one
+	doc, err := ParseDocumentWithOptions([]byte(`{
+		"@context": "https://openvex.dev/ns/v9.9.9",
+		"@id": "https://openvex.dev/docs/example/vex-9fb3463de1b57",
+		"author": "Wolfi J Inkinson",
+		"timestamp": "2023-01-08T18:02:03.647787998-06:00",
+		"version": 1,
+		"statements": []
+	}`), opts)
+	require.NoError(t, err)
+	require.Equal(t, "9.9.9", SpecVersion(doc))
+	require.Len(t, warnings, 1)
+	require.Equal(t, WarningUnknownSpecVersion, warnings[0].Kind)
+	require.Contains(t, warnings[0].Message, "9.9.9")
+
+	// CSAF import problems are reported through the same callback
+	warnings = []Warning{}
+	data, err := os.ReadFile("testdata/csaf/csaf-invalid-status.json")
+	require.NoError(t, err)
+	_, err = ParseDocumentWithOptions(data, opts)
+	require.Error(t, err)
+	require.Len(t, warnings, 1)
+	require.Equal(t, WarningZeroTimestamp, warnings[0].Kind)
+
+	// Nil options use the defaults
+	_, err = ParseDocumentWithOptions([]byte(`{"@context": "https://openvex.dev/ns/v9.9.9"}`), nil)
+	require.NoError(t, err)
+}
+
+func TestOpenURL(t *testing.T) {
+	data, err := os.ReadFile("testdata/v020-1.vex.json")
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/doc.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(data) //nolint:errcheck
+	})
+	mux.HandleFunc("/redirect.json", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/doc.json", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name    string
+		url     string
+		opts    URLOptions
+		mustErr bool
+	}{
+		{"ok", srv.URL + "/doc.json", URLOptions{}, false},
+		{"redirect", srv.URL + "/redirect.json", URLOptions{}, false},
+		{"not found", srv.URL + "/missing.json", URLOptions{}, true},
+		{"too large", srv.URL + "/doc.json", URLOptions{MaxSize: 10}, true},
+		{"invalid scheme", "file:///etc/passwd", URLOptions{}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Client = srv.Client()
+			doc, err := OpenURLWithOptions(context.Background(), tc.url, &tc.opts)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, doc.Statements, 1)
+		})
+	}
+
+	// Nil options use the defaults
+	doc, err := OpenURLWithOptions(context.Background(), srv.URL+"/doc.json", nil)
+	require.NoError(t, err)
+	require.Len(t, doc.Statements, 1)
+}
+
+// writeTestCorpus writes n copies of a test document to dir
+func writeTestCorpus(t testing.TB, dir string, n int) {
+	data, err := os.ReadFile("testdata/v020-1.vex.json")
+	require.NoError(t, err)
+	for i := 0; i < n; i++ {
+		require.NoError(t, os.WriteFile(
+			filepath.Join(dir, fmt.Sprintf("doc-%05d.json", i)), data, os.FileMode(0o644),
+		))
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCorpus(t, dir, 20)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not vex"), os.FileMode(0o644)))
+
+	serial, err := LoadDir(dir, &LoadOptions{})
+	require.NoError(t, err)
+	require.Len(t, serial, 20)
+
+	concurrent, err := LoadDir(dir, &LoadOptions{Workers: 4})
+	require.NoError(t, err)
+	require.Equal(t, serial, concurrent)
+
+	defaults, err := LoadDir(dir, nil)
+	require.NoError(t, err)
+	require.Equal(t, serial, defaults)
+
+	// Broken files make the whole load fail, reporting every one of them
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad-1.json"), []byte("{"), os.FileMode(0o644)))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad-2.json"), []byte("{"), os.FileMode(0o644)))
+	_, err = LoadDir(dir, &LoadOptions{Workers: 4})
+	require.ErrorContains(t, err, "bad-1.json")
+	require.ErrorContains(t, err, "bad-2.json")
+}
+
+func TestLoadDirEmpty(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCorpus(t, dir, 2)
+	empty := vex.New()
+	var b bytes.Buffer
+	require.NoError(t, empty.ToJSON(&b))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.json"), b.Bytes(), os.FileMode(0o644)))
+
+	docs, err := LoadDir(dir, &LoadOptions{})
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	require.True(t, IsEmpty(docs[2]))
+	require.False(t, IsEmpty(docs[0]))
+
+	_, err = LoadDir(dir, &LoadOptions{RejectEmpty: true})
+	require.ErrorIs(t, err, ErrEmptyDocument)
+	require.ErrorContains(t, err, "empty.json")
+}
+
+func TestLoadDirNoProducts(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	productless := testStatement("CVE-2023-0002", "", vex.StatusUnderInvestigation, ts)
+	productless.Products = nil
+	// Products identified only by their purl are applied too
+	git := testStatement("CVE-2023-0001", "", vex.StatusFixed, ts)
+	git.Products[0].Identifiers = map[vex.IdentifierType]string{vex.PURL: "pkg:apk/wolfi/git@1.0.0"}
+	git.Products[0].Hashes = map[vex.Algorithm]vex.Hash{vex.SHA256: "abcd1234"}
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+		git,
+		productless,
+	)
+	var b bytes.Buffer
+	require.NoError(t, doc.ToJSON(&b))
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "doc.json"), b.Bytes(), os.FileMode(0o644)))
+
+	for _, tc := range []struct {
+		behavior   NoProductsBehavior
+		statements int
+		products   []string
+		mustErr    bool
+	}{
+		{NoProductsKeep, 3, nil, false},
+		{NoProductsApplyToAll, 3, []string{"pkg:apk/wolfi/bash@1.0.0", "pkg:apk/wolfi/git@1.0.0"}, false},
+		{NoProductsDrop, 2, nil, false},
+		{NoProductsError, 0, nil, true},
+	} {
+		t.Run(string(tc.behavior), func(t *testing.T) {
+			docs, err := LoadDir(dir, &LoadOptions{NoProducts: tc.behavior})
+			if tc.mustErr {
+				require.ErrorIs(t, err, ErrNoProducts)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, docs, 1)
+			require.Len(t, docs[0].Statements, tc.statements)
+			if tc.behavior == NoProductsDrop {
+				return
+			}
+			ids := []string{}
+			for i := range docs[0].Statements[2].Products {
+				ids = append(ids, productIdentifier(&docs[0].Statements[2].Products[i]))
+			}
+			require.ElementsMatch(t, tc.products, ids)
+			if tc.behavior == NoProductsApplyToAll {
+				require.Equal(t, git.Products[0], docs[0].Statements[2].Products[1])
+			}
+		})
+	}
+}
+
+func TestOpenYAMLMulti(t *testing.T) {
+	docs, err := OpenYAMLMulti("testdata/multi.vex.yaml")
+	require.ErrorContains(t, err, "YAML document #2")
+	require.Len(t, docs, 2)
+	require.Equal(t, "https://openvex.dev/docs/public/vex-multi-1", docs[0].ID)
+	require.Equal(t, "https://openvex.dev/docs/public/vex-multi-2", docs[1].ID)
+	require.Equal(t, vex.StatusUnderInvestigation, docs[1].Statements[0].Status)
+
+	// Malformed YAML stops the stream, returning the documents before it
+	path := filepath.Join(t.TempDir(), "broken.yaml")
+	data, err := os.ReadFile("testdata/multi.vex.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(data, []byte("---\nstatements: [\n")...), os.FileMode(0o644)))
+	docs, err = OpenYAMLMulti(path)
+	require.ErrorContains(t, err, "decoding YAML document #4")
+	require.Len(t, docs, 2)
+}
+
+func BenchmarkLoadDir(b *testing.B) {
+	dir := b.TempDir()
+	writeTestCorpus(b, dir, 2000)
+	for _, workers := range []int{1, 8} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := LoadDir(dir, &LoadOptions{Workers: workers}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	doc, problems, err := ParseLenient([]byte(`{
+		"@context": "https://openvex.dev/ns/v0.2.0",
+		"@id": "test-doc",
+		"author": "John Doe",
+		"timestamp": "2023-01-01T00:00:00Z",
+		"statements": [
+			{
+				"vulnerability": {"name": "CVE-2023-0001"},
+				"products": [{"@id": "pkg:apk/wolfi/bash@1.0.0"}],
+				"status": "fixed"
+			},
+			{
+				"vulnerability": {"name": "CVE-2023-0002"},
+				"products": [{"@id": "pkg:apk/wolfi/bash@1.0.0"}],
+				"status": "cheese"
+			},
+			{
+				"vulnerability": {"name": "CVE-2023-0003"},
+				"products": [{"@id": "pkg:apk/wolfi/bash@1.0.0"}],
+				"status": "not_affected"
+			},
+			{
+				"vulnerability": {"name": "CVE-2023-0004"},
+				"products": [{"@id": "pkg:apk/wolfi/bash@1.0.0"}],
+				"status": "not_affected",
+				"justification": "component_not_present"
+			}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Len(t, doc.Statements, 2)
+	require.Equal(t, "CVE-2023-0001", string(doc.Statements[0].Vulnerability.Name))
+	require.Equal(t, "CVE-2023-0004", string(doc.Statements[1].Vulnerability.Name))
+
+	require.Len(t, problems, 2)
+	require.Equal(t, 1, problems[0].Index)
+	require.Equal(t, "CVE-2023-0002", string(problems[0].Statement.Vulnerability.Name))
+	require.Equal(t, 2, problems[1].Index)
+
+	_, _, err = ParseLenient([]byte("not json"))
+	require.Error(t, err)
+}
+
+func TestOpenTar(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	olderDoc := testDocument(t1, testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, t1))
+	olderDoc.ID = "older"
+	newerDoc := testDocument(t2, testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, t2))
+	newerDoc.ID = "newer"
+
+	var older, newer bytes.Buffer
+	require.NoError(t, olderDoc.ToJSON(&older))
+	require.NoError(t, newerDoc.ToJSON(&newer))
+	yamlDoc, err := yaml.JSONToYAML(older.Bytes())
+	require.NoError(t, err)
+
+	// Build an archive with the newer document first
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"advisories/newer.json", newer.Bytes()},
+		{"advisories/older.yaml", yamlDoc},
+		{"README.md", []byte("# These are not the documents you are looking for")},
+		{"advisories/broken.json", []byte("{")},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: f.name, Mode: 0o644, Size: int64(len(f.data)), Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(f.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	_, err = gz.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	for name, data := range map[string][]byte{"plain": buf.Bytes(), "gzipped": gzBuf.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			docs, err := OpenTar(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Len(t, docs, 2)
+			require.Equal(t, "older", docs[0].ID)
+			require.Equal(t, "newer", docs[1].ID)
+		})
+	}
+
+	_, err = OpenTar(bytes.NewReader([]byte("not a tarball")))
+	require.Error(t, err)
+}
+
+func TestMigrate(t *testing.T) {
+	// A synthetic v0.0.1 document, missing its timestamp and author
+	doc, err := Migrate([]byte(`{
+		"@context": "https://openvex.dev/ns",
+		"@id": "legacy-doc",
+		"format": "text/vex+json",
+		"statements": [
+			{
+				"timestamp": "2023-02-01T00:00:00Z",
+				"products": ["pkg:apk/wolfi/bash@1.0.0"],
+				"vulnerability": "CVE-2023-0002",
+				"status": "fixed"
+			},
+			{
+				"timestamp": "2023-01-01T00:00:00Z",
+				"products": ["pkg:apk/wolfi/bash@1.0.0"],
+				"subcomponents": ["pkg:golang/example.com/lib@v1.0.0"],
+				"vulnerability": "CVE-2023-0001",
+				"status": "not_affected",
+				"justification": "component_not_present"
+			}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, vex.ContextLocator(), doc.Context)
+	require.Equal(t, vex.SpecVersion, SpecVersion(doc))
+	require.Equal(t, "legacy-doc", doc.ID)
+	require.Equal(t, DefaultAuthor, doc.Author)
+	require.Equal(t, 1, doc.Version)
+	require.NotNil(t, doc.Timestamp)
+	require.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), doc.Timestamp.UTC())
+	require.NotNil(t, doc.LastUpdated)
+	require.Equal(t, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), doc.LastUpdated.UTC())
+
+	require.Len(t, doc.Statements, 2)
+	require.Equal(t, "CVE-2023-0001", string(doc.Statements[1].Vulnerability.Name))
+	require.Equal(t, "pkg:apk/wolfi/bash@1.0.0", doc.Statements[1].Products[0].ID)
+	require.Equal(t, "pkg:golang/example.com/lib@v1.0.0", doc.Statements[1].Products[0].Subcomponents[0].ID)
+	require.Empty(t, Lint(doc))
+
+	// Documents in the current version keep their data
+	data, err := os.ReadFile("testdata/v020-1.vex.json")
+	require.NoError(t, err)
+	current, err := ParseDocument(data)
+	require.NoError(t, err)
+	migrated, err := Migrate(data)
+	require.NoError(t, err)
+	require.Equal(t, current.Statements, migrated.Statements)
+	require.Equal(t, current.Author, migrated.Author)
+
+	_, err = Migrate([]byte(`{"@context": "https://example.com/"}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// markdownStatuses is the order of the status sections of the Markdown
+// report, from the ones requiring the most attention to the least
+var markdownStatuses = []vex.Status{
+	vex.StatusAffected, vex.StatusUnderInvestigation, vex.StatusFixed,
+	vex.StatusNotAffected, StatusUnknown,
+}
+
+// ToMarkdown writes a document as a Markdown report, ready to post in wikis
+// or pull request comments. The report has a table with the document
+// metadata followed by a section per status listing its statements.
+// Vulnerabilities with an HTTP(S) IRI are rendered as links to it.
+func ToMarkdown(doc *vex.VEX, w io.Writer) error {
+	var b strings.Builder
+
+	title := "VEX document"
+	if doc.ID != "" {
+		title = fmt.Sprintf("VEX document %s", markdownCell(doc.ID))
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+
+	b.WriteString("| Field | Value |\n| --- | --- |\n")
+	for _, field := range []struct{ name, value string }{
+		{"Author", doc.Author},
+		{"Role", doc.AuthorRole},
+		{"Supplier", doc.Supplier},
+		{"Version", fmt.Sprintf("%d", doc.Version)},
+		{"Timestamp", markdownTime(doc.Timestamp)},
+		{"Last updated", markdownTime(doc.LastUpdated)},
+	} {
+		if field.value == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", field.name, markdownCell(field.value))
+	}
+
+	groups := GroupByStatus(doc)
+	for _, status := range markdownStatuses {
+		statements := groups[status]
+		if len(statements) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", status, len(statements))
+		b.WriteString("| Vulnerability | Products | Details | Timestamp |\n| --- | --- | --- | --- |\n")
+		for _, s := range statements { //nolint:gocritic // this IS supposed to copy
+			s = cascadeTimestamp(doc, s)
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				markdownVulnerability(&s.Vulnerability), markdownProducts(s.Products),
+				markdownCell(statementDetails(&s)), markdownTime(s.Timestamp),
+			)
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("writing markdown report: %w", err)
+	}
+	return nil
+}
+
+// statementDetails returns the free form data explaining a statement: the
+// action statement of affected statements, the justification and impact of
+// not_affected ones and the status notes of all of them
+func statementDetails(s *vex.Statement) string {
+	details := []string{}
+	switch s.Status {
+	case vex.StatusAffected:
+		details = append(details, s.ActionStatement)
+	case vex.StatusNotAffected:
+		details = append(details, string(s.Justification), s.ImpactStatement)
+	}
+	details = append(details, s.StatusNotes)
+
+	nonEmpty := []string{}
+	for _, d := range details {
+		if d != "" {
+			nonEmpty = append(nonEmpty, d)
+		}
+	}
+	return strings.Join(nonEmpty, ". ")
+}
+
+// markdownVulnerability renders the name of a vulnerability, linked to its
+// IRI when it is a web address
+func markdownVulnerability(v *vex.Vulnerability) string {
+	name := markdownCell(string(v.Name))
+	if strings.HasPrefix(v.ID, "https://") || strings.HasPrefix(v.ID, "http://") {
+		return fmt.Sprintf("[%s](%s)", name, v.ID)
+	}
+	return name
+}
+
+// markdownProducts renders a list of products as code spans
+func markdownProducts(products []vex.Product) string {
+	ids := []string{}
+	for i := range products {
+		id := productIdentifier(&products[i])
+		ids = append(ids, "`"+strings.ReplaceAll(id, "`", "'")+"`")
+	}
+	return strings.Join(ids, "<br>")
+}
+
+// markdownTime formats a date for the report, returning an empty string
+// when it is not set
+func markdownTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// markdownCell escapes a value so it can be written in a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
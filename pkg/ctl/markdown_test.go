@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestToMarkdown(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 12, 30, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+
+	notAffected := testStatement("CVE-2023-0001", bash, vex.StatusNotAffected, t1)
+	notAffected.Products = append(notAffected.Products, vex.Product{Component: vex.Component{ID: "pkg:apk/wolfi/git@2.0.0"}})
+	notAffected.ImpactStatement = "The vulnerable code is not built"
+	affected := testStatement("CVE-2023-0002", bash, vex.StatusAffected, t2)
+	affected.Vulnerability.ID = "https://nvd.nist.gov/vuln/detail/CVE-2023-0002"
+	affected.ActionStatement = "Upgrade to 1.0.1 | 2.0.0\nor disable the module"
+	undated := testStatement("CVE-2023-0003", bash, vex.StatusUnderInvestigation, t1)
+	undated.Timestamp = nil
+	undated.StatusNotes = "Waiting on upstream"
+
+	doc := testDocument(t1, notAffected, affected, undated)
+	doc.ID = "https://openvex.dev/docs/example/vex-9fb3463de1b57"
+	doc.Author = "Wolfi J Inkinson"
+
+	var b bytes.Buffer
+	require.NoError(t, ToMarkdown(doc, &b))
+	golden, err := os.ReadFile("testdata/markdown/report.md")
+	require.NoError(t, err)
+	require.Equal(t, string(golden), b.String())
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"strings"
+
+	purl "github.com/package-url/packageurl-go"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// StatementsForProduct returns the statements in a document that apply to a
+// product. Besides the regular product matching, purls are matched by their
+// content digest: a statement about a purl applies to any purl of the same
+// package carrying the same digest, regardless of their versions or other
+// qualifiers. This lets consumers holding an image by digest find the
+// statements written about it, even when they were issued about a tag.
+//
+// Digests are read from the purl version when it is a digest, as in OCI
+// purls, and from the digest and checksum qualifiers.
+//
+// Identifiers are only compared to product identifiers of the same scheme
+// (see ProductScheme). CPEs are compared ignoring case, as CPE names are
+// case insensitive.
+func StatementsForProduct(doc *vex.VEX, product string) []vex.Statement {
+	ss := []vex.Statement{}
+	for i := range doc.Statements {
+		for j := range doc.Statements[i].Products {
+			if productMatches(&doc.Statements[i].Products[j], product) {
+				ss = append(ss, doc.Statements[i])
+				break
+			}
+		}
+	}
+	return ss
+}
+
+// ProductMatcher decides if a product identifier queried by a consumer
+// matches an identifier declared in a statement. Organizations identify their
+// products differently, a matcher lets them plug their own rules into the
+// queries without forking them.
+type ProductMatcher interface {
+	// Matches returns true if the queried identifier matches the declared
+	// one. Declared hashes are passed as their digest, see
+	// DeclaredIdentifiers.
+	Matches(query, declared string) bool
+}
+
+// ProductMatcherFunc adapts a function to a ProductMatcher
+type ProductMatcherFunc func(query, declared string) bool
+
+// Matches calls the function
+func (f ProductMatcherFunc) Matches(query, declared string) bool {
+	return f(query, declared)
+}
+
+// DefaultProductMatcher is the matcher implementing the rules of
+// StatementsForProduct: identifiers match exactly, purls match by package
+// and version or by content digest, CPEs match ignoring case and hashes
+// are matched by their value or by the digests of a purl.
+var DefaultProductMatcher ProductMatcher = defaultProductMatcher{}
+
+type defaultProductMatcher struct{}
+
+// digestAlgorithms are the hash algorithms of the digests DeclaredIdentifiers
+// lists, without dashes
+var digestAlgorithms = map[string]struct{}{}
+
+func init() {
+	for _, algo := range []vex.Algorithm{
+		vex.MD5, vex.SHA1, vex.SHA256, vex.SHA384, vex.SHA512,
+		vex.SHA3224, vex.SHA3256, vex.SHA3384, vex.SHA3512,
+		vex.BLAKE2S256, vex.BLAKE2B256, vex.BLAKE2B512, vex.BLAKE3,
+	} {
+		digestAlgorithms[digestAlgorithm(algo)] = struct{}{}
+	}
+}
+
+// digestAlgorithm returns the name of a hash algorithm used in digests
+func digestAlgorithm(algo vex.Algorithm) string {
+	return strings.ReplaceAll(string(algo), "-", "")
+}
+
+func (defaultProductMatcher) Matches(query, declared string) bool {
+	if query == "" || declared == "" {
+		return false
+	}
+	if query == declared {
+		return true
+	}
+
+	queryScheme := ProductScheme(query)
+	switch ProductScheme(declared) {
+	case SchemePurl:
+		return queryScheme == SchemePurl && (vex.PurlMatches(declared, query) || purlsShareDigest(query, declared))
+	case SchemeCPE:
+		return queryScheme == SchemeCPE && strings.EqualFold(query, declared)
+	}
+
+	algo, h, ok := strings.Cut(declared, ":")
+	if _, known := digestAlgorithms[algo]; !ok || !known {
+		return false
+	}
+	if queryScheme == SchemePurl {
+		q, err := purl.FromString(query)
+		if err != nil {
+			return false
+		}
+		_, ok := purlDigests(&q)[declared]
+		return ok
+	}
+	return strings.EqualFold(query, h)
+}
+
+// purlsShareDigest returns true if two purls of the same package carry the
+// same digest
+func purlsShareDigest(a, b string) bool {
+	pa, err := purl.FromString(a)
+	if err != nil {
+		return false
+	}
+	pb, err := purl.FromString(b)
+	if err != nil {
+		return false
+	}
+	if pa.Type != pb.Type || pa.Namespace != pb.Namespace || pa.Name != pb.Name {
+		return false
+	}
+	digests := purlDigests(&pb)
+	for d := range purlDigests(&pa) {
+		if _, ok := digests[d]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DeclaredIdentifiers returns the identifiers a product declares, as they are
+// passed to a ProductMatcher: its ID, the values of its identifiers and its
+// hashes as digests of the form algo:hex, with the algorithm without dashes
+// and the value in lowercase (eg sha256:e3b0c442...).
+func DeclaredIdentifiers(product *vex.Product) []string {
+	ids := []string{}
+	if product.ID != "" {
+		ids = append(ids, product.ID)
+	}
+	for _, id := range product.Identifiers {
+		ids = append(ids, id)
+	}
+	for algo, h := range product.Hashes {
+		ids = append(ids, digestAlgorithm(algo)+":"+strings.ToLower(string(h)))
+	}
+	return ids
+}
+
+// MatchOptions control how products are matched by the queries
+type MatchOptions struct {
+	// Matcher matches the products. When nil, DefaultProductMatcher is
+	// used.
+	Matcher ProductMatcher
+}
+
+// StatementsForProductWithOptions returns the statements in a document that
+// apply to a product like StatementsForProduct, matching products with the
+// matcher in the options. Nil options use the regular rules.
+func StatementsForProductWithOptions(doc *vex.VEX, product string, opts *MatchOptions) []vex.Statement {
+	if opts == nil || opts.Matcher == nil {
+		return StatementsForProduct(doc, product)
+	}
+	ss := []vex.Statement{}
+	for i := range doc.Statements {
+		if statementMatches(&doc.Statements[i], product, opts.Matcher) {
+			ss = append(ss, doc.Statements[i])
+		}
+	}
+	return ss
+}
+
+// statementMatches returns true if a matcher matches an identifier with any
+// of the products of a statement
+func statementMatches(s *vex.Statement, query string, m ProductMatcher) bool {
+	for i := range s.Products {
+		if productMatchesWith(&s.Products[i], query, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// productMatchesWith returns true if a matcher matches an identifier with any
+// of the identifiers declared by a product
+func productMatchesWith(p *vex.Product, query string, m ProductMatcher) bool {
+	for _, declared := range DeclaredIdentifiers(p) {
+		if m.Matches(query, declared) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProductsOutsideAllowlist returns the IDs of the products referenced in a
+// document that don't match any of the allowed identifiers, in the order
+// they first appear. Products are matched as in StatementsForProduct. A
+// policy gate can use it to stop documents that would disclose internal
+// product identifiers from being published.
+func ProductsOutsideAllowlist(doc *vex.VEX, allowed []string) []string {
+	outside := []string{}
+	seen := map[string]struct{}{}
+	for i := range doc.Statements {
+		for j := range doc.Statements[i].Products {
+			p := &doc.Statements[i].Products[j]
+			id := productIdentifier(p)
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+
+			matched := false
+			for _, a := range allowed {
+				if productMatches(p, a) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				outside = append(outside, id)
+			}
+		}
+	}
+	return outside
+}
+
+// StatementsForHash returns the statements in a document about products
+// identified by an artifact hash. Digests are compared ignoring case.
+func StatementsForHash(doc *vex.VEX, algo vex.Algorithm, digest string) []vex.Statement {
+	ss := []vex.Statement{}
+	for i := range doc.Statements {
+		for j := range doc.Statements[i].Products {
+			if productHasHash(&doc.Statements[i].Products[j].Component, algo, digest) {
+				ss = append(ss, doc.Statements[i])
+				break
+			}
+		}
+	}
+	return ss
+}
+
+// productHasHash returns true if a component lists a hash
+func productHasHash(c *vex.Component, algo vex.Algorithm, digest string) bool {
+	h, ok := c.Hashes[algo]
+	return ok && digest != "" && strings.EqualFold(string(h), digest)
+}
+
+// productMatches returns true if a product matches an identifier, either by
+// the regular matching rules or by the rules of the identifier scheme
+func productMatches(product *vex.Product, identifier string) bool {
+	if product.Matches(identifier, "") {
+		return true
+	}
+
+	switch ProductScheme(identifier) {
+	case SchemePurl:
+		return productMatchesDigest(product, identifier)
+	case SchemeCPE:
+		for _, cpe := range ComponentCPEs(&product.Component) {
+			if strings.EqualFold(cpe, identifier) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// productMatchesDigest returns true if a product shares a digest with a purl
+func productMatchesDigest(product *vex.Product, identifier string) bool {
+	query, err := purl.FromString(identifier)
+	if err != nil {
+		return false
+	}
+	queryDigests := purlDigests(&query)
+	if len(queryDigests) == 0 {
+		return false
+	}
+
+	for _, c := range ComponentPurls(&product.Component) {
+		p, err := purl.FromString(c)
+		if err != nil {
+			continue
+		}
+		if p.Type != query.Type || p.Namespace != query.Namespace || p.Name != query.Name {
+			continue
+		}
+		for d := range purlDigests(&p) {
+			if _, ok := queryDigests[d]; ok {
+				return true
+			}
+		}
+	}
+
+	// Purls of the same package can also be checked against the hashes
+	// of the product
+	for algo, h := range product.Hashes {
+		d := digestAlgorithm(algo) + ":" + strings.ToLower(string(h))
+		if _, ok := queryDigests[d]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// purlDigests returns the set of digests in a purl normalized as algo:hex
+func purlDigests(p *purl.PackageURL) map[string]struct{} {
+	digests := map[string]struct{}{}
+	values := []string{p.Version}
+	for k, v := range p.Qualifiers.Map() {
+		if k == "digest" || k == "checksum" {
+			// Checksums may be a comma separated list
+			values = append(values, strings.Split(v, ",")...)
+		}
+	}
+
+	for _, v := range values {
+		algo, hex, ok := strings.Cut(strings.ToLower(v), ":")
+		if !ok || algo == "" || hex == "" {
+			continue
+		}
+		digests[strings.ReplaceAll(algo, "-", "")+":"+hex] = struct{}{}
+	}
+	return digests
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	purl "github.com/package-url/packageurl-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestStatementsForProduct(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	hashed := testStatement("CVE-2023-0004", "", vex.StatusFixed, ts)
+	hashed.Products[0].Hashes = map[vex.Algorithm]vex.Hash{vex.SHA256: "ABCD1234"}
+
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", "pkg:oci/app@sha256%3Aabcd1234?repository_url=ghcr.io/example/app&tag=v1", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0002", "pkg:oci/app?digest=sha256%3Aabcd1234", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0003", "pkg:oci/other@sha256%3Aabcd1234", vex.StatusFixed, ts),
+		hashed,
+		testStatement("CVE-2023-0005", "pkg:oci/app@sha256%3A99999999", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0006", "pkg:oci/app", vex.StatusFixed, ts),
+	)
+
+	for _, tc := range []struct {
+		name     string
+		product  string
+		expected []string
+	}{
+		{
+			"digest in version",
+			"pkg:oci/app@sha256%3Aabcd1234",
+			[]string{"CVE-2023-0001", "CVE-2023-0002", "CVE-2023-0004", "CVE-2023-0006"},
+		},
+		{
+			"digest in qualifier with different version",
+			"pkg:oci/app@v1?digest=sha256:ABCD1234",
+			[]string{"CVE-2023-0001", "CVE-2023-0002", "CVE-2023-0004", "CVE-2023-0006"},
+		},
+		{
+			"different digest",
+			"pkg:oci/app@sha256%3A99999999",
+			[]string{"CVE-2023-0005", "CVE-2023-0006"},
+		},
+		{
+			"no digest",
+			"pkg:oci/app@v1",
+			[]string{"CVE-2023-0006"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vulns := []string{}
+			for _, s := range StatementsForProduct(doc, tc.product) {
+				vulns = append(vulns, string(s.Vulnerability.Name))
+			}
+			require.Equal(t, tc.expected, vulns)
+
+			// The default matcher implements the same rules
+			vulns = []string{}
+			for _, s := range StatementsForProductWithOptions(doc, tc.product, &MatchOptions{Matcher: DefaultProductMatcher}) {
+				vulns = append(vulns, string(s.Vulnerability.Name))
+			}
+			require.Equal(t, tc.expected, vulns)
+		})
+	}
+}
+
+func TestProductMatcher(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusAffected, ts),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@2.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0003", "pkg:apk/wolfi/curl@1.0.0", vex.StatusAffected, ts),
+	)
+
+	// A matcher ignoring the purl versions
+	unversioned := func(id string) string {
+		p, err := purl.FromString(id)
+		if err != nil {
+			return id
+		}
+		p.Version = ""
+		return p.ToString()
+	}
+	opts := &MatchOptions{Matcher: ProductMatcherFunc(func(query, declared string) bool {
+		return unversioned(query) == unversioned(declared)
+	})}
+
+	require.Len(t, StatementsForProduct(doc, "pkg:apk/wolfi/bash@3.0.0"), 0)
+	ss := StatementsForProductWithOptions(doc, "pkg:apk/wolfi/bash@3.0.0", opts)
+	require.Len(t, ss, 2)
+	require.Equal(t, "CVE-2023-0001", string(ss[0].Vulnerability.Name))
+	require.Equal(t, "CVE-2023-0002", string(ss[1].Vulnerability.Name))
+
+	require.Empty(t, EffectiveStatuses([]*vex.VEX{doc}, "pkg:apk/wolfi/bash@3.0.0"))
+	require.Equal(t, map[string]vex.Status{
+		"CVE-2023-0001": vex.StatusAffected,
+		"CVE-2023-0002": vex.StatusFixed,
+	}, EffectiveStatusesWithOptions([]*vex.VEX{doc}, "pkg:apk/wolfi/bash@3.0.0", opts))
+
+	// Without a matcher the regular rules apply
+	require.Equal(t,
+		EffectiveStatuses([]*vex.VEX{doc}, "pkg:apk/wolfi/curl@1.0.0"),
+		EffectiveStatusesWithOptions([]*vex.VEX{doc}, "pkg:apk/wolfi/curl@1.0.0", &MatchOptions{}),
+	)
+	require.Equal(t,
+		EffectiveStatuses([]*vex.VEX{doc}, "pkg:apk/wolfi/curl@1.0.0"),
+		EffectiveStatusesWithOptions([]*vex.VEX{doc}, "pkg:apk/wolfi/curl@1.0.0", nil),
+	)
+	require.Equal(t,
+		StatementsForProduct(doc, "pkg:apk/wolfi/curl@1.0.0"),
+		StatementsForProductWithOptions(doc, "pkg:apk/wolfi/curl@1.0.0", nil),
+	)
+}
+
+func TestProductMatcherDigest(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	image := "pkg:oci/app@sha256%3Aabcd1234?repository_url=ghcr.io/example/app"
+	query := "pkg:oci/app?digest=sha256:abcd1234"
+	doc := testDocument(ts, testStatement("CVE-2023-0001", image, vex.StatusAffected, ts))
+	docs := []*vex.VEX{doc}
+
+	// All the queries find the statement by the digest of the product
+	require.Len(t, StatementsForProduct(doc, query), 1)
+	require.Equal(t, map[string]vex.Status{"CVE-2023-0001": vex.StatusAffected}, EffectiveStatuses(docs, query))
+	require.Equal(t, vex.StatusAffected, WorstEffectiveStatus(docs, query))
+	affected, err := IsAffected(docs, "CVE-2023-0001", query)
+	require.NoError(t, err)
+	require.True(t, affected)
+	require.Equal(t, docs, RelevantDocuments(docs, "CVE-2023-0001", query))
+}
+
+func TestProductsOutsideAllowlist(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	internal := testStatement("CVE-2023-0002", "pkg:generic/acme/internal-tool@1.0.0", vex.StatusFixed, ts)
+	internal.Products = append(internal.Products, vex.Product{Component: vex.Component{ID: "pkg:apk/wolfi/bash@1.0.0"}})
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/git@2.0.0", vex.StatusFixed, ts),
+		internal,
+	)
+
+	allowed := []string{"pkg:apk/wolfi/bash@1.0.0", "pkg:apk/wolfi/git@2.0.0"}
+	require.Equal(t, []string{"pkg:generic/acme/internal-tool@1.0.0"}, ProductsOutsideAllowlist(doc, allowed))
+	require.Empty(t, ProductsOutsideAllowlist(doc, append(allowed, "pkg:generic/acme/internal-tool@1.0.0")))
+	require.Len(t, ProductsOutsideAllowlist(doc, nil), 3)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// StreamToNDJSON writes a VEX document as newline delimited JSON: the first
+// line holds the document metadata and each of the following lines one of
+// the statements received from the channel. Statements are written as they
+// arrive, so documents of any size can be written with bounded memory. The
+// function returns once the channel is closed or when a write fails, in which
+// case the rest of the channel is left undrained.
+func StreamToNDJSON(w io.Writer, meta *vex.Metadata, statements <-chan vex.Statement) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(meta); err != nil {
+		return fmt.Errorf("writing metadata: %w", err)
+	}
+
+	i := 0
+	for s := range statements { //nolint:gocritic // this IS supposed to copy
+		if err := enc.Encode(&s); err != nil {
+			return fmt.Errorf("writing statement #%d: %w", i, err)
+		}
+		i++
+	}
+	return nil
+}
+
+// NDJSONReader reads the VEX documents written by StreamToNDJSON one
+// statement at a time
+type NDJSONReader struct {
+	// Metadata of the document, read from its first line
+	Metadata *vex.Metadata
+
+	dec   *json.Decoder
+	index int
+}
+
+// NewNDJSONReader returns a reader of a newline delimited JSON VEX document.
+// The metadata line is read when the reader is created.
+func NewNDJSONReader(r io.Reader) (*NDJSONReader, error) {
+	dec := json.NewDecoder(r)
+	meta := &vex.Metadata{}
+	if err := dec.Decode(meta); err != nil {
+		return nil, fmt.Errorf("reading metadata: %w", err)
+	}
+	return &NDJSONReader{Metadata: meta, dec: dec}, nil
+}
+
+// Next returns the next statement in the document. It returns io.EOF when
+// there are no more statements.
+func (nr *NDJSONReader) Next() (*vex.Statement, error) {
+	s := &vex.Statement{}
+	if err := nr.dec.Decode(s); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading statement #%d: %w", nr.index, err)
+	}
+	nr.index++
+	return s, nil
+}
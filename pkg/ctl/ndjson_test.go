@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestStreamToNDJSON(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(ts)
+	doc.ID = "streamed-doc"
+
+	statements := make(chan vex.Statement)
+	go func() {
+		defer close(statements)
+		for i := 0; i < 100; i++ {
+			statements <- testStatement(fmt.Sprintf("CVE-2023-%04d", i), "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)
+		}
+	}()
+
+	var b bytes.Buffer
+	require.NoError(t, StreamToNDJSON(&b, &doc.Metadata, statements))
+	require.Len(t, strings.Split(strings.TrimSpace(b.String()), "\n"), 101)
+
+	reader, err := NewNDJSONReader(&b)
+	require.NoError(t, err)
+	require.Equal(t, "streamed-doc", reader.Metadata.ID)
+	require.Equal(t, doc.Timestamp.UTC(), reader.Metadata.Timestamp.UTC())
+
+	n := 0
+	for {
+		s, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("CVE-2023-%04d", n), string(s.Vulnerability.Name))
+		n++
+	}
+	require.Equal(t, 100, n)
+
+	_, err = NewNDJSONReader(strings.NewReader("not json"))
+	require.Error(t, err)
+}
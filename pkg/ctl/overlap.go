@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"sort"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// Overlap describes two statements about the same vulnerability with
+// different statuses whose product lists intersect without being the same
+type Overlap struct {
+	Vulnerability string   `json:"vulnerability"`
+	Statements    [2]int   `json:"statements"` // Indices of the statements in the document
+	Products      []string `json:"products"`   // Products listed in both statements, sorted
+}
+
+// OverlapWarnings returns the pairs of statements in a document that make
+// the status of some products ambiguous: statements about the same
+// vulnerability, with different statuses, that share some but not all of
+// their products. Statements with the same product lists are not reported,
+// those read as a plain status change.
+func OverlapWarnings(doc *vex.VEX) []Overlap {
+	overlaps := []Overlap{}
+	for i := range doc.Statements {
+		a := &doc.Statements[i]
+		for j := i + 1; j < len(doc.Statements); j++ {
+			b := &doc.Statements[j]
+			if a.Status == b.Status || !b.Vulnerability.Matches(string(a.Vulnerability.Name)) {
+				continue
+			}
+
+			shared, partial := productOverlap(a.Products, b.Products)
+			if len(shared) == 0 || !partial {
+				continue
+			}
+			overlaps = append(overlaps, Overlap{
+				Vulnerability: string(a.Vulnerability.Name),
+				Statements:    [2]int{i, j},
+				Products:      shared,
+			})
+		}
+	}
+	return overlaps
+}
+
+// productOverlap returns the sorted identifiers of the products in both lists
+// (see productIdentifier) and whether any product is only in one of them.
+// Products without an ID or purl are ignored.
+func productOverlap(a, b []vex.Product) (shared []string, partial bool) {
+	inA := map[string]struct{}{}
+	for i := range a {
+		if id := productIdentifier(&a[i]); id != "" {
+			inA[id] = struct{}{}
+		}
+	}
+	inB := map[string]struct{}{}
+	shared = []string{}
+	for i := range b {
+		id := productIdentifier(&b[i])
+		if id == "" {
+			continue
+		}
+		if _, ok := inB[id]; ok {
+			continue
+		}
+		inB[id] = struct{}{}
+		if _, ok := inA[id]; ok {
+			shared = append(shared, id)
+		} else {
+			partial = true
+		}
+	}
+	for id := range inA {
+		if _, ok := inB[id]; !ok {
+			partial = true
+		}
+	}
+	sort.Strings(shared)
+	return shared, partial
+}
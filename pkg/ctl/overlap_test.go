@@ -0,0 +1,55 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestOverlapWarnings(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@1.0.0"
+	curl := "pkg:apk/wolfi/curl@1.0.0"
+
+	withProducts := func(s vex.Statement, ids ...string) vex.Statement { //nolint:gocritic // this IS supposed to copy
+		for _, id := range ids {
+			s.Products = append(s.Products, vex.Product{Component: vex.Component{ID: id}})
+		}
+		return s
+	}
+
+	withPurl := func(s vex.Statement, purl string) vex.Statement { //nolint:gocritic // this IS supposed to copy
+		s.Products = append(s.Products, vex.Product{Component: vex.Component{
+			Identifiers: map[vex.IdentifierType]string{vex.PURL: purl},
+		}})
+		return s
+	}
+
+	doc := testDocument(ts,
+		withProducts(testStatement("CVE-2023-0001", bash, vex.StatusAffected, ts), git),
+		withProducts(testStatement("CVE-2023-0001", git, vex.StatusFixed, ts), curl),
+		// Same products, different status: not a partial overlap
+		withProducts(testStatement("CVE-2023-0002", bash, vex.StatusAffected, ts), git),
+		withProducts(testStatement("CVE-2023-0002", git, vex.StatusFixed, ts), bash),
+		// Partial overlap with the same status is not ambiguous
+		withProducts(testStatement("CVE-2023-0003", bash, vex.StatusFixed, ts), git),
+		testStatement("CVE-2023-0003", git, vex.StatusFixed, ts),
+		// Products are compared by purl when they have no ID, and products
+		// without either are ignored
+		withPurl(withProducts(testStatement("CVE-2023-0004", bash, vex.StatusAffected, ts), ""), git),
+		withProducts(testStatement("CVE-2023-0004", git, vex.StatusFixed, ts), bash),
+	)
+
+	require.Equal(t, []Overlap{
+		{Vulnerability: "CVE-2023-0001", Statements: [2]int{0, 1}, Products: []string{git}},
+	}, OverlapWarnings(doc))
+}
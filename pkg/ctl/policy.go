@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// PolicyRule is a named predicate over the statements of a document. Check
+// returns a message describing why a statement violates the rule or an
+// empty string if the statement complies with it. Statements are passed to
+// Check with the document timestamp cascaded.
+type PolicyRule struct {
+	Name  string
+	Check func(*vex.Statement) string
+}
+
+// Policy is a set of rules that the statements in a document must satisfy
+type Policy []PolicyRule
+
+// Violation records a statement that does not satisfy a policy rule
+type Violation struct {
+	Rule      string        // Name of the violated rule
+	Index     int           // Position of the statement in the document
+	Statement vex.Statement // The offending statement
+	Message   string
+}
+
+// CheckPolicy checks every statement in a document against all the rules in
+// a policy and returns the violations found. The list is empty if the
+// document complies with the policy.
+func CheckPolicy(doc *vex.VEX, policy Policy) []Violation {
+	violations := []Violation{}
+	for i := range doc.Statements {
+		s := cascadeTimestamp(doc, doc.Statements[i])
+		for _, rule := range policy {
+			if msg := rule.Check(&s); msg != "" {
+				violations = append(violations, Violation{
+					Rule: rule.Name, Index: i, Statement: s, Message: msg,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// ForStatus returns a rule that only checks statements with a status
+func ForStatus(status vex.Status, rule PolicyRule) PolicyRule {
+	return PolicyRule{
+		Name: rule.Name,
+		Check: func(s *vex.Statement) string {
+			if s.Status != status {
+				return ""
+			}
+			return rule.Check(s)
+		},
+	}
+}
+
+// RequireJustification is a rule requiring not_affected statements to have
+// a machine readable justification, free form impact statements are not
+// enough to comply.
+var RequireJustification = ForStatus(vex.StatusNotAffected, PolicyRule{
+	Name: "require-justification",
+	Check: func(s *vex.Statement) string {
+		if s.Justification == "" {
+			return "not_affected statement has no justification"
+		}
+		return ""
+	},
+})
+
+// RequireAction is a rule requiring affected statements to have an action
+// statement
+var RequireAction = ForStatus(vex.StatusAffected, PolicyRule{
+	Name: "require-action",
+	Check: func(s *vex.Statement) string {
+		if s.ActionStatement == "" {
+			return "affected statement has no action statement"
+		}
+		return ""
+	},
+})
+
+// NoStale returns a rule that flags vulnerabilities that have been under
+// investigation for longer than maxAge, measured up to TimeSource
+func NoStale(maxAge time.Duration) PolicyRule {
+	return ForStatus(vex.StatusUnderInvestigation, PolicyRule{
+		Name: "no-stale",
+		Check: func(s *vex.Statement) string {
+			if s.Timestamp == nil {
+				return "statement has no timestamp"
+			}
+			if age := TimeSource().Sub(*s.Timestamp); age > maxAge {
+				return fmt.Sprintf("under investigation since %s", s.Timestamp.Format(time.RFC3339))
+			}
+			return ""
+		},
+	})
+}
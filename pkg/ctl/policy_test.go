@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	old := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Hour)
+	defer func(original func() time.Time) { TimeSource = original }(TimeSource)
+	TimeSource = func() time.Time { return now }
+
+	unjustified := testStatement("CVE-2023-0001", product, vex.StatusNotAffected, old)
+	unjustified.Justification = ""
+	unjustified.ImpactStatement = "The vulnerable code is never called"
+	noAction := testStatement("CVE-2023-0002", product, vex.StatusAffected, old)
+	noAction.ActionStatement = ""
+
+	doc := testDocument(old,
+		unjustified,
+		noAction,
+		testStatement("CVE-2023-0003", product, vex.StatusUnderInvestigation, old),
+		testStatement("CVE-2023-0004", product, vex.StatusUnderInvestigation, recent),
+		testStatement("CVE-2023-0005", product, vex.StatusNotAffected, old),
+		testStatement("CVE-2023-0006", product, vex.StatusAffected, old),
+	)
+	// Statements without timestamp get the one from the document
+	doc.Statements[2].Timestamp = nil
+
+	// Custom rules can be composed with the built in ones
+	noWontFix := PolicyRule{
+		Name: "no-wont-fix",
+		Check: func(s *vex.Statement) string {
+			if strings.Contains(s.ActionStatement, "won't fix") {
+				return "vulnerabilities must be fixed"
+			}
+			return ""
+		},
+	}
+	doc.Statements[5].ActionStatement = "We won't fix this"
+
+	violations := CheckPolicy(doc, Policy{
+		RequireJustification, RequireAction, NoStale(30 * 24 * time.Hour), noWontFix,
+	})
+	got := []string{}
+	for _, v := range violations {
+		got = append(got, v.Rule+" "+string(v.Statement.Vulnerability.Name))
+		require.NotEmpty(t, v.Message)
+	}
+	require.Equal(t, []string{
+		"require-justification CVE-2023-0001",
+		"require-action CVE-2023-0002",
+		"no-stale CVE-2023-0003",
+		"no-wont-fix CVE-2023-0006",
+	}, got)
+	require.Equal(t, 2, violations[2].Index)
+
+	require.Empty(t, CheckPolicy(doc, Policy{}))
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"fmt"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// ProfileRule is a check a consumer profile runs on a document in addition
+// to the ones of Lint
+type ProfileRule func(doc *vex.VEX) []Finding
+
+// Profile captures the requirements a specific consumer of VEX data, such
+// as a scanner, places on the documents it reads
+type Profile struct {
+	Name  string
+	Rules []ProfileRule
+}
+
+var (
+	// PurlProfile is the profile of consumers that match products by
+	// package URL, such as SBOM based scanners. All products must have a
+	// purl as their ID or among their identifiers.
+	PurlProfile = Profile{
+		Name:  "purl",
+		Rules: []ProfileRule{RequirePurlProducts},
+	}
+
+	// StrictProfile adds to PurlProfile the requirement of machine readable
+	// justifications on all not_affected statements, for consumers that
+	// cannot process free form impact statements.
+	StrictProfile = Profile{
+		Name:  "strict",
+		Rules: []ProfileRule{RequirePurlProducts, RequireJustifications},
+	}
+)
+
+// ValidateProfile lints a document and checks it against the rules of a
+// consumer profile. The returned findings include those of Lint followed by
+// the ones of the profile rules, so an empty list means the document is
+// valid and compatible with the consumer. Profile rules can make a Lint
+// finding stricter: a profile finding with the same pointer and message as
+// a Lint one replaces it.
+func ValidateProfile(doc *vex.VEX, profile Profile) []Finding {
+	profileFindings := []Finding{}
+	overrides := map[string]struct{}{}
+	for _, rule := range profile.Rules {
+		for _, f := range rule(doc) {
+			profileFindings = append(profileFindings, f)
+			overrides[f.Pointer+"\x00"+f.Message] = struct{}{}
+		}
+	}
+
+	findings := []Finding{}
+	for _, f := range Lint(doc) {
+		if _, ok := overrides[f.Pointer+"\x00"+f.Message]; !ok {
+			findings = append(findings, f)
+		}
+	}
+	return append(findings, profileFindings...)
+}
+
+// RequirePurlProducts is a profile rule that flags the products that cannot
+// be identified by a package URL
+func RequirePurlProducts(doc *vex.VEX) []Finding {
+	findings := []Finding{}
+	for i := range doc.Statements {
+		for j := range doc.Statements[i].Products {
+			p := &doc.Statements[i].Products[j]
+			if len(ComponentPurls(&p.Component)) > 0 {
+				continue
+			}
+			findings = append(findings, Finding{
+				Level:   LevelError,
+				Message: fmt.Sprintf("statement #%d: product %q has no package URL", i, p.ID),
+				Pointer: fmt.Sprintf("/statements/%d/products/%d", i, j),
+			})
+		}
+	}
+	return findings
+}
+
+// RequireJustifications is a profile rule that turns the Lint warnings about
+// not_affected statements without a machine readable justification into
+// errors
+func RequireJustifications(doc *vex.VEX) []Finding {
+	findings := lintJustifications(doc)
+	for i := range findings {
+		findings[i].Level = LevelError
+	}
+	return findings
+}
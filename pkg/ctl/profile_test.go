@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestValidateProfile(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAffected := testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusNotAffected, ts)
+	notAffected.Justification = ""
+	notAffected.ImpactStatement = "The vulnerable code is never called"
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+		notAffected,
+	)
+
+	// The missing justification is only a warning in the purl profile but
+	// an error in the strict one
+	findings := ValidateProfile(doc, PurlProfile)
+	require.Len(t, findings, 1)
+	require.Equal(t, LevelWarning, findings[0].Level)
+	findings = ValidateProfile(doc, StrictProfile)
+	require.Len(t, findings, 1)
+	require.Equal(t, "/statements/1/justification", findings[0].Pointer)
+	require.Equal(t, LevelError, findings[0].Level)
+
+	// Custom profiles can define their own rules
+	noFixed := Profile{
+		Name: "no-fixed",
+		Rules: []ProfileRule{func(doc *vex.VEX) []Finding {
+			findings := []Finding{}
+			for i := range doc.Statements {
+				if doc.Statements[i].Status == vex.StatusFixed {
+					findings = append(findings, Finding{Level: LevelError, Message: "fixed"})
+				}
+			}
+			return findings
+		}},
+	}
+	require.Len(t, ValidateProfile(doc, noFixed), 2)
+
+	// Products without purls fail the purl profile
+	doc.Statements[0].Products[0].ID = "cpe:2.3:a:gnu:bash:1.0.0:*:*:*:*:*:*:*"
+	findings = ValidateProfile(doc, PurlProfile)
+	require.Len(t, findings, 2)
+	require.Equal(t, "/statements/0/products/0", findings[1].Pointer)
+}
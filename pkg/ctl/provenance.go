@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"sigs.k8s.io/release-utils/version"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+const (
+	// ProvenancePredicateType is the predicate type of the provenance
+	// attestations emitted by vexctl
+	ProvenancePredicateType = "https://slsa.dev/provenance/v1"
+
+	// ProvenanceBuilderID identifies vexctl as the builder of a document
+	ProvenanceBuilderID = "https://github.com/openvex/vexctl"
+
+	// ProvenanceBuildType is the type of the builds vexctl records
+	ProvenanceBuildType = "https://openvex.dev/vexctl/build@v1"
+)
+
+// Provenance records how a VEX document was generated, following the
+// structure of the SLSA v1 provenance predicate
+type Provenance struct {
+	BuildDefinition ProvenanceBuildDefinition `json:"buildDefinition"`
+	RunDetails      ProvenanceRunDetails      `json:"runDetails"`
+}
+
+// ProvenanceBuildDefinition describes the inputs used to generate a document
+type ProvenanceBuildDefinition struct {
+	BuildType            string                 `json:"buildType"`
+	ExternalParameters   map[string]any         `json:"externalParameters"`
+	ResolvedDependencies []ProvenanceDependency `json:"resolvedDependencies,omitempty"`
+}
+
+// ProvenanceDependency is one of the artifacts a document was generated from,
+// such as a source CSAF document
+type ProvenanceDependency struct {
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// ProvenanceRunDetails identifies the builder that generated a document
+type ProvenanceRunDetails struct {
+	Builder ProvenanceBuilder `json:"builder"`
+}
+
+// ProvenanceBuilder identifies the tool that generated a document
+type ProvenanceBuilder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// NewProvenance returns a provenance record with the vexctl builder data
+// and no inputs
+func NewProvenance() *Provenance {
+	return &Provenance{
+		BuildDefinition: ProvenanceBuildDefinition{
+			BuildType:          ProvenanceBuildType,
+			ExternalParameters: map[string]any{},
+		},
+		RunDetails: ProvenanceRunDetails{
+			Builder: ProvenanceBuilder{
+				ID: ProvenanceBuilderID,
+				Version: map[string]string{
+					"vexctl": version.GetVersionInfo().GitVersion,
+				},
+			},
+		},
+	}
+}
+
+// AddInput records an artifact the document was generated from, identified
+// by its URI and the hex encoded sha256 digest of its contents
+func (p *Provenance) AddInput(uri, sha256Digest string) {
+	p.BuildDefinition.ResolvedDependencies = append(
+		p.BuildDefinition.ResolvedDependencies,
+		ProvenanceDependency{URI: uri, Digest: map[string]string{"sha256": sha256Digest}},
+	)
+}
+
+// Attestation returns an in-toto statement with the provenance as its
+// predicate. The subject of the statement is the VEX document, identified by
+// the digest of its canonical serialization, so the attestation can be
+// distributed alongside the document.
+func (p *Provenance) Attestation(doc *vex.VEX) (*intoto.Statement, error) {
+	digest, err := Digest(doc)
+	if err != nil {
+		return nil, fmt.Errorf("computing document digest: %w", err)
+	}
+	return &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: ProvenancePredicateType,
+			Subject: []intoto.Subject{
+				{Name: doc.ID, Digest: map[string]string{"sha256": digest}},
+			},
+		},
+		Predicate: p,
+	}, nil
+}
+
+// WriteAttestation writes the provenance attestation of a document as JSON
+func (p *Provenance) WriteAttestation(doc *vex.VEX, w io.Writer) error {
+	att, err := p.Attestation(doc)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(att); err != nil {
+		return fmt.Errorf("encoding provenance attestation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenanceFromCSAF(t *testing.T) {
+	path := "testdata/csaf/csaf.json"
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+
+	prov := NewProvenance()
+	doc, _, err := OpenCSAFWithOptions(path, &CSAFOptions{Provenance: prov})
+	require.NoError(t, err)
+
+	require.Equal(t, ProvenanceBuilderID, prov.RunDetails.Builder.ID)
+	require.Len(t, prov.BuildDefinition.ResolvedDependencies, 1)
+	require.Equal(t, path, prov.BuildDefinition.ResolvedDependencies[0].URI)
+	require.Equal(t, hex.EncodeToString(sum[:]), prov.BuildDefinition.ResolvedDependencies[0].Digest["sha256"])
+
+	var b bytes.Buffer
+	require.NoError(t, prov.WriteAttestation(doc, &b))
+
+	var att struct {
+		PredicateType string `json:"predicateType"`
+		Subject       []struct {
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+		Predicate Provenance `json:"predicate"`
+	}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &att))
+	require.Equal(t, ProvenancePredicateType, att.PredicateType)
+
+	digest, err := Digest(doc)
+	require.NoError(t, err)
+	require.Len(t, att.Subject, 1)
+	require.Equal(t, digest, att.Subject[0].Digest["sha256"])
+	require.Equal(t, prov.BuildDefinition.ResolvedDependencies, att.Predicate.BuildDefinition.ResolvedDependencies)
+}
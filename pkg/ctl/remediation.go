@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// RemediationSource is a knowledge base of remediation guidance, such as a
+// vulnerability database. Lookup returns the action to take to remediate a
+// vulnerability, or an empty string if it has none.
+type RemediationSource interface {
+	Lookup(vulnID string) (string, error)
+}
+
+// BackfillActions fills the empty action statements of the affected
+// statements in a document with the guidance found in a remediation source.
+// Statements that already have an action and those with other statuses are
+// not touched. Each vulnerability is looked up only once. Lookup errors do
+// not stop the backfill, they are returned joined once all statements are
+// processed.
+func BackfillActions(doc *vex.VEX, src RemediationSource) error {
+	type result struct {
+		action string
+		err    error
+	}
+	cache := map[vex.VulnerabilityID]result{}
+
+	errs := []error{}
+	for i := range doc.Statements {
+		s := &doc.Statements[i]
+		if s.Status != vex.StatusAffected || s.ActionStatement != "" {
+			continue
+		}
+
+		r, ok := cache[s.Vulnerability.Name]
+		if !ok {
+			r.action, r.err = src.Lookup(string(s.Vulnerability.Name))
+			if r.err != nil {
+				errs = append(errs, fmt.Errorf("looking up %s: %w", s.Vulnerability.Name, r.err))
+			}
+			cache[s.Vulnerability.Name] = r
+		}
+		if r.err == nil {
+			s.ActionStatement = r.action
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Remediation is an entry of a remediation plan: the action to take to
+// remediate a vulnerability in a set of products
+type Remediation struct {
+	Vulnerability string     `json:"vulnerability"`
+	Products      []string   `json:"products"`
+	Action        string     `json:"action"`
+	Timestamp     *time.Time `json:"timestamp,omitempty"`
+}
+
+// RemediationPlan returns the actions to take on the affected statements of a
+// document. Only the effective statement of each vulnerability and product is
+// considered, so vulnerabilities fixed in later statements are not listed.
+// OpenVEX has no severity information, so the plan is sorted by
+// vulnerability and date.
+func RemediationPlan(doc *vex.VEX) []Remediation {
+	affected := []vex.Statement{}
+	seen := map[string]struct{}{}
+	for _, product := range documentProducts(doc) {
+		for _, s := range effectiveStatements([]*vex.VEX{doc}, product) { //nolint:gocritic // this IS supposed to copy
+			if s.Status != vex.StatusAffected {
+				continue
+			}
+			// Statements listing more than one product are only returned once
+			key := statementKey(s)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			affected = append(affected, s)
+		}
+	}
+	vex.SortStatements(affected, time.Time{})
+
+	plan := make([]Remediation, 0, len(affected))
+	for i := range affected {
+		products := []string{}
+		for j := range affected[i].Products {
+			if id := productIdentifier(&affected[i].Products[j]); id != "" {
+				products = append(products, id)
+			}
+		}
+		plan = append(plan, Remediation{
+			Vulnerability: string(affected[i].Vulnerability.Name),
+			Products:      products,
+			Action:        affected[i].ActionStatement,
+			Timestamp:     affected[i].Timestamp,
+		})
+	}
+	return plan
+}
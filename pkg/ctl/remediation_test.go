@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+type fakeRemediationSource map[string]string
+
+func (src fakeRemediationSource) Lookup(vulnID string) (string, error) {
+	if vulnID == "CVE-2023-0666" {
+		return "", errors.New("database unavailable")
+	}
+	return src[vulnID], nil
+}
+
+func TestBackfillActions(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	src := fakeRemediationSource{
+		"CVE-2023-0001": "Upgrade to 1.0.1",
+		"CVE-2023-0002": "Upgrade to 1.0.2",
+		"CVE-2023-0003": "Upgrade to 1.0.3",
+	}
+
+	empty := testStatement("CVE-2023-0001", product, vex.StatusAffected, ts)
+	empty.ActionStatement = ""
+	failing := testStatement("CVE-2023-0666", product, vex.StatusAffected, ts)
+	failing.ActionStatement = ""
+	doc := testDocument(ts,
+		empty,
+		testStatement("CVE-2023-0002", product, vex.StatusAffected, ts),
+		testStatement("CVE-2023-0003", product, vex.StatusUnderInvestigation, ts),
+		failing,
+	)
+
+	err := BackfillActions(doc, src)
+	require.ErrorContains(t, err, "CVE-2023-0666")
+	require.Equal(t, "Upgrade to 1.0.1", doc.Statements[0].ActionStatement)
+	require.Equal(t, "Upgrade to the latest version", doc.Statements[1].ActionStatement, "existing actions must be preserved")
+	require.Empty(t, doc.Statements[2].ActionStatement)
+	require.Empty(t, doc.Statements[3].ActionStatement)
+}
+
+func TestRemediationPlan(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@2.0.0"
+
+	multi := testStatement("CVE-2023-0002", bash, vex.StatusAffected, t1)
+	// Products identified only by their purl are listed too
+	multi.Products = append(multi.Products, vex.Product{Component: vex.Component{
+		Identifiers: map[vex.IdentifierType]string{vex.PURL: git},
+	}})
+	multi.ActionStatement = "Upgrade bash and git"
+	doc := testDocument(t1,
+		testStatement("CVE-2023-0003", bash, vex.StatusAffected, t1),
+		multi,
+		testStatement("CVE-2023-0001", bash, vex.StatusNotAffected, t1),
+		testStatement("CVE-2023-0004", bash, vex.StatusUnderInvestigation, t1),
+		// Affected, then fixed later
+		testStatement("CVE-2023-0005", bash, vex.StatusAffected, t1),
+		testStatement("CVE-2023-0005", bash, vex.StatusFixed, t2),
+	)
+
+	plan := RemediationPlan(doc)
+	require.Equal(t, []Remediation{
+		{Vulnerability: "CVE-2023-0002", Products: []string{bash, git}, Action: "Upgrade bash and git", Timestamp: &t1},
+		{Vulnerability: "CVE-2023-0003", Products: []string{bash}, Action: "Upgrade to the latest version", Timestamp: &t1},
+	}, plan)
+
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"action":"Upgrade bash and git"`)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	gosarif "github.com/owenrumney/go-sarif/sarif"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// ToSARIFSuppressions writes a SARIF report with one run containing a
+// suppressed result for each vulnerability and product whose effective
+// statement in the document is not_affected or fixed, resolved like
+// SuppressedFindings. Scanners and code scanning interfaces can use it to
+// hide the findings that VEX statements resolve.
+//
+// Results are keyed by the vulnerability as their rule ID and the product
+// identifier as their logical location. Both are also recorded as partial
+// fingerprints to ease matching them to findings.
+func ToSARIFSuppressions(doc *vex.VEX, w io.Writer) error {
+	return ToSARIFSuppressionsWithOptions(doc, w, &ExportOptions{})
+}
+
+// ToSARIFSuppressionsWithOptions works like ToSARIFSuppressions, translating
+// the statuses and justifications in the messages with the vocabulary in the
+// options.
+func ToSARIFSuppressionsWithOptions(doc *vex.VEX, w io.Writer, opts *ExportOptions) error {
+	vocabulary := opts.vocabulary()
+	report, err := gosarif.New(gosarif.Version210)
+	if err != nil {
+		return fmt.Errorf("creating SARIF report: %w", err)
+	}
+
+	run := gosarif.NewRun("vexctl", "https://github.com/openvex/vexctl")
+	for _, sup := range effectiveSuppressions(doc) {
+		s := &sup.statement
+		vuln := string(s.Vulnerability.Name)
+		run.AddRule(vuln)
+		run.AddResult(vuln).
+			WithMessage(gosarif.NewTextMessage(fmt.Sprintf("%s is %s in %s", vuln, vocabulary.Status(s.Status), sup.product))).
+			WithLocation(&gosarif.Location{
+				LogicalLocations: []*gosarif.LogicalLocation{
+					gosarif.NewLogicalLocation().WithFullyQualifiedName(sup.product).WithKind("package"),
+				},
+			}).
+			WithPartialFingerPrints(map[string]interface{}{
+				"vulnerability": vuln,
+				"product":       sup.product,
+			}).
+			WithSuppression(
+				gosarif.NewSuppression("external").
+					WithStatus("accepted").
+					WithJustifcation(suppressionJustification(s, vocabulary)),
+			)
+	}
+	report.AddRun(run)
+
+	if err := report.PrettyWrite(w); err != nil {
+		return fmt.Errorf("writing SARIF report: %w", err)
+	}
+	return nil
+}
+
+// SuppressedFinding is a vulnerability that a scanner should not report in a
+// product, as a VEX statement declares it not_affected or fixed
+type SuppressedFinding struct {
+	Vulnerability string            `json:"vulnerability"`
+	Product       string            `json:"product"`
+	Status        vex.Status        `json:"status"`
+	Justification vex.Justification `json:"justification,omitempty"`
+}
+
+// SuppressedFindings returns the findings a scanner should suppress
+// according to a document: one for each vulnerability and product whose
+// effective statement (see EffectiveStatuses) is not_affected or fixed, so
+// statements overridden by a later one are not included. Findings are sorted
+// by vulnerability name, then by the order of the products in the document.
+// Products without an identifier are not included.
+func SuppressedFindings(doc *vex.VEX) []SuppressedFinding {
+	findings := []SuppressedFinding{}
+	for _, sup := range effectiveSuppressions(doc) {
+		findings = append(findings, SuppressedFinding{
+			Vulnerability: string(sup.statement.Vulnerability.Name),
+			Product:       sup.product,
+			Status:        sup.statement.Status,
+			Justification: sup.statement.Justification,
+		})
+	}
+	return findings
+}
+
+// suppression is the effective statement that resolves the findings of a
+// vulnerability in a product
+type suppression struct {
+	product   string
+	statement vex.Statement
+}
+
+// effectiveSuppressions returns the effective statements of each product in
+// a document that resolve the findings of their vulnerability, sorted by
+// vulnerability name and then by the order of the products in the document
+func effectiveSuppressions(doc *vex.VEX) []suppression {
+	suppressions := []suppression{}
+	for _, product := range documentProducts(doc) {
+		for _, s := range effectiveStatements([]*vex.VEX{doc}, product) { //nolint:gocritic // this IS supposed to copy
+			if suppresses(&s) {
+				suppressions = append(suppressions, suppression{product: product, statement: s})
+			}
+		}
+	}
+	sort.SliceStable(suppressions, func(i, j int) bool {
+		return suppressions[i].statement.Vulnerability.Name < suppressions[j].statement.Vulnerability.Name
+	})
+	return suppressions
+}
+
+// suppresses returns true if a statement resolves the findings of its
+// vulnerability in its products
+func suppresses(s *vex.Statement) bool {
+	return s.Status == vex.StatusNotAffected || s.Status == vex.StatusFixed
+}
+
+// productIdentifier returns the ID of a product, or its purl when it has no ID
+func productIdentifier(p *vex.Product) string {
+	if p.ID != "" {
+		return p.ID
+	}
+	return p.Identifiers[vex.PURL]
+}
+
+// suppressionJustification builds the justification text of a suppression
+// from the data in its statement
+func suppressionJustification(s *vex.Statement, vocabulary VocabularyMapper) string {
+	parts := []string{}
+	switch s.Status {
+	case vex.StatusNotAffected:
+		if s.Justification != "" {
+			parts = append(parts, vocabulary.Justification(s.Justification))
+		}
+		if s.ImpactStatement != "" {
+			parts = append(parts, s.ImpactStatement)
+		}
+	case vex.StatusFixed:
+		parts = append(parts, vocabulary.Status(vex.StatusFixed))
+	}
+	if s.StatusNotes != "" {
+		parts = append(parts, s.StatusNotes)
+	}
+	return strings.Join(parts, ": ")
+}
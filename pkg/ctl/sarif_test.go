@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	gosarif "github.com/owenrumney/go-sarif/sarif"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestToSARIFSuppressions(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@2.0.0"
+
+	notAffected := testStatement("CVE-2023-0001", bash, vex.StatusNotAffected, ts)
+	notAffected.Products = append(notAffected.Products, vex.Product{Component: vex.Component{ID: git}})
+	notAffected.ImpactStatement = "bash is compiled without the module"
+	fixed := testStatement("CVE-2023-0002", bash, vex.StatusFixed, ts)
+	fixed.StatusNotes = "Fixed in the 1.0.0 release"
+
+	doc := testDocument(ts,
+		notAffected,
+		fixed,
+		testStatement("CVE-2023-0003", bash, vex.StatusAffected, ts),
+		testStatement("CVE-2023-0004", bash, vex.StatusUnderInvestigation, ts),
+	)
+
+	var b bytes.Buffer
+	require.NoError(t, ToSARIFSuppressions(doc, &b))
+	report, err := gosarif.FromBytes(b.Bytes())
+	require.NoError(t, err)
+	require.Len(t, report.Runs, 1)
+
+	results := report.Runs[0].Results
+	require.Len(t, results, 3, "only not_affected and fixed statements are suppressions")
+	for i, tc := range []struct {
+		vuln          string
+		product       string
+		justification string
+	}{
+		{"CVE-2023-0001", bash, "component_not_present: bash is compiled without the module"},
+		{"CVE-2023-0001", git, "component_not_present: bash is compiled without the module"},
+		{"CVE-2023-0002", bash, "fixed: Fixed in the 1.0.0 release"},
+	} {
+		require.Equal(t, tc.vuln, *results[i].RuleID)
+		require.Equal(t, tc.product, *results[i].Locations[0].LogicalLocations[0].FullyQualifiedName)
+		require.Equal(t, tc.product, results[i].PartialFingerprints["product"])
+		require.Len(t, results[i].Suppressions, 1)
+		require.Equal(t, "external", results[i].Suppressions[0].Kind)
+		require.Equal(t, tc.justification, *results[i].Suppressions[0].Justification)
+	}
+	require.Len(t, report.Runs[0].Tool.Driver.Rules, 2)
+
+	// A newer affected statement overrides an older not_affected one
+	later := ts.Add(time.Hour)
+	doc = testDocument(ts,
+		notAffected,
+		testStatement("CVE-2023-0001", bash, vex.StatusAffected, later),
+	)
+	b.Reset()
+	require.NoError(t, ToSARIFSuppressions(doc, &b))
+	report, err = gosarif.FromBytes(b.Bytes())
+	require.NoError(t, err)
+	require.Len(t, report.Runs[0].Results, 1)
+	require.Equal(t, git, report.Runs[0].Results[0].PartialFingerprints["product"])
+}
+
+func TestSuppressedFindings(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@1.0.0"
+
+	notAffected := testStatement("CVE-2023-0001", bash, vex.StatusNotAffected, ts)
+	notAffected.Products = append(notAffected.Products, vex.Product{Component: vex.Component{ID: git}})
+	doc := testDocument(ts,
+		notAffected,
+		testStatement("CVE-2023-0002", bash, vex.StatusAffected, ts),
+		testStatement("CVE-2023-0003", bash, vex.StatusUnderInvestigation, ts),
+		testStatement("CVE-2023-0004", git, vex.StatusFixed, ts),
+	)
+
+	require.Equal(t, []SuppressedFinding{
+		{Vulnerability: "CVE-2023-0001", Product: bash, Status: vex.StatusNotAffected, Justification: vex.ComponentNotPresent},
+		{Vulnerability: "CVE-2023-0001", Product: git, Status: vex.StatusNotAffected, Justification: vex.ComponentNotPresent},
+		{Vulnerability: "CVE-2023-0004", Product: git, Status: vex.StatusFixed},
+	}, SuppressedFindings(doc))
+
+	// A newer affected statement overrides an older not_affected one
+	later := ts.Add(time.Hour)
+	doc = testDocument(ts,
+		notAffected,
+		testStatement("CVE-2023-0001", bash, vex.StatusAffected, later),
+	)
+	require.Equal(t, []SuppressedFinding{
+		{Vulnerability: "CVE-2023-0001", Product: git, Status: vex.StatusNotAffected, Justification: vex.ComponentNotPresent},
+	}, SuppressedFindings(doc))
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// SBOM is the minimal interface vexctl needs from a software bill of
+// materials to reconcile it against VEX documents. Any SBOM format can be
+// plugged in by implementing it.
+type SBOM interface {
+	// PackageURLs returns the purls of all the components in the SBOM
+	PackageURLs() []string
+}
+
+// BomRef is the identifier type of the products referenced by the bom-ref of
+// a component in a CycloneDX SBOM
+const BomRef vex.IdentifierType = "bom-ref"
+
+// BomRefResolver is implemented by the SBOMs that identify their components
+// with bom-refs (CycloneDX). ResolveBomRef returns the purl of the component
+// with a bom-ref.
+type BomRefResolver interface {
+	ResolveBomRef(ref string) (string, bool)
+}
+
+// ResolveBomRefs returns a copy of a document where the products and
+// subcomponents identified by a bom-ref get the purl of the matching SBOM
+// component added to their identifiers, so they can be matched as any other
+// purl. Components that already have a purl identifier are not modified. An
+// error is returned if the SBOM does not support bom-refs or if any bom-ref
+// is not found in it.
+func ResolveBomRefs(doc *vex.VEX, sbom SBOM) (*vex.VEX, error) {
+	resolver, ok := sbom.(BomRefResolver)
+	if !ok {
+		return nil, errors.New("SBOM does not support bom-refs")
+	}
+
+	unresolved := []string{}
+	resolve := func(c vex.Component) vex.Component { //nolint:gocritic // this IS supposed to copy
+		ref, ok := c.Identifiers[BomRef]
+		if !ok {
+			return c
+		}
+		if _, ok := c.Identifiers[vex.PURL]; ok {
+			return c
+		}
+		purl, ok := resolver.ResolveBomRef(ref)
+		if !ok {
+			unresolved = append(unresolved, ref)
+			return c
+		}
+		identifiers := make(map[vex.IdentifierType]string, len(c.Identifiers)+1)
+		for k, v := range c.Identifiers {
+			identifiers[k] = v
+		}
+		identifiers[vex.PURL] = purl
+		c.Identifiers = identifiers
+		return c
+	}
+
+	newDoc := &vex.VEX{
+		Metadata:   doc.Metadata,
+		Statements: make([]vex.Statement, len(doc.Statements)),
+	}
+	for i := range doc.Statements {
+		s := doc.Statements[i]
+		if s.Products != nil {
+			s.Products = make([]vex.Product, len(doc.Statements[i].Products))
+		}
+		for j, p := range doc.Statements[i].Products { //nolint:gocritic // this IS supposed to copy
+			p.Component = resolve(p.Component)
+			if p.Subcomponents != nil {
+				p.Subcomponents = make([]vex.Subcomponent, len(p.Subcomponents))
+			}
+			for k, sc := range doc.Statements[i].Products[j].Subcomponents {
+				sc.Component = resolve(sc.Component)
+				p.Subcomponents[k] = sc
+			}
+			s.Products[j] = p
+		}
+		newDoc.Statements[i] = s
+	}
+
+	if len(unresolved) > 0 {
+		return nil, fmt.Errorf("bom-refs not found in SBOM: %s", strings.Join(uniqueSorted(unresolved), ", "))
+	}
+	return newDoc, nil
+}
+
+// ReconcileReport captures how the products in a VEX document map to the
+// components of an SBOM. All lists are sorted purls.
+type ReconcileReport struct {
+	Matched       []string // VEX products found in the SBOM
+	UnmatchedVEX  []string // VEX products not found in the SBOM
+	UncoveredSBOM []string // SBOM components not covered by any VEX product
+}
+
+// Reconcile matches the purls of the products in a VEX document against the
+// components in an SBOM. Matching follows the rules of vex.PurlMatches, so a
+// VEX product without a version or qualifiers covers all the components
+// that only differ in those.
+func Reconcile(doc *vex.VEX, sbom SBOM) (*ReconcileReport, error) {
+	if doc == nil {
+		return nil, errors.New("no VEX document specified")
+	}
+	if sbom == nil {
+		return nil, errors.New("no SBOM specified")
+	}
+
+	report := &ReconcileReport{
+		Matched:       []string{},
+		UnmatchedVEX:  []string{},
+		UncoveredSBOM: []string{},
+	}
+
+	components := uniqueSorted(sbom.PackageURLs())
+	covered := map[string]struct{}{}
+	for _, productPurl := range documentPurls(doc) {
+		found := false
+		for _, componentPurl := range components {
+			if vex.PurlMatches(productPurl, componentPurl) {
+				covered[componentPurl] = struct{}{}
+				found = true
+			}
+		}
+		if found {
+			report.Matched = append(report.Matched, productPurl)
+		} else {
+			report.UnmatchedVEX = append(report.UnmatchedVEX, productPurl)
+		}
+	}
+
+	for _, componentPurl := range components {
+		if _, ok := covered[componentPurl]; !ok {
+			report.UncoveredSBOM = append(report.UncoveredSBOM, componentPurl)
+		}
+	}
+
+	return report, nil
+}
+
+// documentPurls returns the sorted purls of the products in a document
+func documentPurls(doc *vex.VEX) []string {
+	purls := []string{}
+	for i := range doc.Statements {
+		for _, p := range doc.Statements[i].Products {
+			if strings.HasPrefix(p.ID, "pkg:") {
+				purls = append(purls, p.ID)
+			}
+			if id, ok := p.Identifiers[vex.PURL]; ok {
+				purls = append(purls, id)
+			}
+		}
+	}
+	return uniqueSorted(purls)
+}
+
+// uniqueSorted returns a sorted copy of a list without duplicates
+func uniqueSorted(list []string) []string {
+	seen := map[string]struct{}{}
+	ret := []string{}
+	for _, s := range list {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		ret = append(ret, s)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// ParseSBOM parses SBOM data in the CycloneDX or SPDX JSON formats. Only the
+// data needed to reconcile the SBOM is read from the document.
+func ParseSBOM(data []byte) (SBOM, error) {
+	format := struct {
+		BOMFormat   string `json:"bomFormat"`
+		SPDXVersion string `json:"spdxVersion"`
+	}{}
+	if err := json.Unmarshal(data, &format); err != nil {
+		return nil, fmt.Errorf("parsing SBOM: %w", err)
+	}
+
+	var sbom SBOM
+	switch {
+	case format.BOMFormat == "CycloneDX":
+		sbom = &cycloneDXSBOM{}
+	case format.SPDXVersion != "":
+		sbom = &spdxSBOM{}
+	default:
+		return nil, errors.New("unable to detect SBOM format")
+	}
+
+	if err := json.Unmarshal(data, sbom); err != nil {
+		return nil, fmt.Errorf("parsing SBOM: %w", err)
+	}
+	return sbom, nil
+}
+
+type cycloneDXComponent struct {
+	BomRef     string               `json:"bom-ref"`
+	Purl       string               `json:"purl"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+// cycloneDXSBOM reads the component tree of a CycloneDX document
+type cycloneDXSBOM struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+func (bom *cycloneDXSBOM) PackageURLs() []string {
+	purls := []string{}
+	var walk func([]cycloneDXComponent)
+	walk = func(components []cycloneDXComponent) {
+		for i := range components {
+			if components[i].Purl != "" {
+				purls = append(purls, components[i].Purl)
+			}
+			walk(components[i].Components)
+		}
+	}
+	walk(bom.Components)
+	return purls
+}
+
+func (bom *cycloneDXSBOM) ResolveBomRef(ref string) (string, bool) {
+	var find func([]cycloneDXComponent) (string, bool)
+	find = func(components []cycloneDXComponent) (string, bool) {
+		for i := range components {
+			if components[i].BomRef == ref && components[i].Purl != "" {
+				return components[i].Purl, true
+			}
+			if purl, ok := find(components[i].Components); ok {
+				return purl, true
+			}
+		}
+		return "", false
+	}
+	return find(bom.Components)
+}
+
+// spdxSBOM reads the package purls of an SPDX document
+type spdxSBOM struct {
+	Packages []struct {
+		ExternalRefs []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+func (bom *spdxSBOM) PackageURLs() []string {
+	purls := []string{}
+	for _, p := range bom.Packages {
+		for _, ref := range p.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				purls = append(purls, ref.ReferenceLocator)
+			}
+		}
+	}
+	return purls
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestParseSBOM(t *testing.T) {
+	expected := []string{
+		"pkg:apk/wolfi/bash@1.0.0?arch=x86_64",
+		"pkg:apk/wolfi/readline@8.2?arch=x86_64",
+		"pkg:apk/wolfi/ncurses@6.4?arch=x86_64",
+	}
+	for _, path := range []string{
+		"testdata/sbom/bash.cdx.json", "testdata/sbom/bash.spdx.json",
+	} {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		sbom, err := ParseSBOM(data)
+		require.NoError(t, err, path)
+		require.Equal(t, expected, sbom.PackageURLs(), path)
+	}
+
+	_, err := ParseSBOM([]byte(`{"name": "not an sbom"}`))
+	require.Error(t, err)
+}
+
+func TestReconcile(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(ts,
+		// Less specific purls cover the SBOM components
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/readline", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0003", "pkg:apk/wolfi/curl@8.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0004", "not-a-purl", vex.StatusFixed, ts),
+	)
+
+	for _, path := range []string{
+		"testdata/sbom/bash.cdx.json", "testdata/sbom/bash.spdx.json",
+	} {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		sbom, err := ParseSBOM(data)
+		require.NoError(t, err)
+
+		report, err := Reconcile(doc, sbom)
+		require.NoError(t, err)
+		require.Equal(t, &ReconcileReport{
+			Matched:       []string{"pkg:apk/wolfi/bash@1.0.0", "pkg:apk/wolfi/readline"},
+			UnmatchedVEX:  []string{"pkg:apk/wolfi/curl@8.0.0"},
+			UncoveredSBOM: []string{"pkg:apk/wolfi/ncurses@6.4?arch=x86_64"},
+		}, report, path)
+	}
+
+	_, err := Reconcile(doc, nil)
+	require.Error(t, err)
+}
+
+func TestResolveBomRefs(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bomRefStatement := func(vuln, ref string) vex.Statement {
+		s := testStatement(vuln, "", vex.StatusFixed, ts)
+		s.Products[0].Identifiers = map[vex.IdentifierType]string{BomRef: ref}
+		return s
+	}
+	doc := testDocument(ts,
+		bomRefStatement("CVE-2023-0001", "bash/readline"),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+	)
+
+	data, err := os.ReadFile("testdata/sbom/bash.cdx.json")
+	require.NoError(t, err)
+	cdx, err := ParseSBOM(data)
+	require.NoError(t, err)
+
+	resolved, err := ResolveBomRefs(doc, cdx)
+	require.NoError(t, err)
+	require.Equal(t, "pkg:apk/wolfi/readline@8.2?arch=x86_64", resolved.Statements[0].Products[0].Identifiers[vex.PURL])
+	require.Equal(t, doc.Statements[1], resolved.Statements[1])
+	require.NotContains(t, doc.Statements[0].Products[0].Identifiers, vex.PURL, "source document must not be modified")
+
+	// The resolved statement now matches the SBOM component
+	matched := StatementsForProduct(resolved, "pkg:apk/wolfi/readline@8.2?arch=x86_64")
+	require.Len(t, matched, 1)
+
+	doc.Statements = append(doc.Statements, bomRefStatement("CVE-2023-0003", "curl"))
+	_, err = ResolveBomRefs(doc, cdx)
+	require.ErrorContains(t, err, "curl")
+
+	data, err = os.ReadFile("testdata/sbom/bash.spdx.json")
+	require.NoError(t, err)
+	spdx, err := ParseSBOM(data)
+	require.NoError(t, err)
+	_, err = ResolveBomRefs(doc, spdx)
+	require.Error(t, err)
+}
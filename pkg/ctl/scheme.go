@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"strings"
+
+	purl "github.com/package-url/packageurl-go"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// Scheme is the kind of identifier used to name a product
+type Scheme string
+
+const (
+	SchemePurl    Scheme = "purl"
+	SchemeCPE     Scheme = "cpe"
+	SchemeUnknown Scheme = "unknown"
+)
+
+// ProductScheme returns the scheme of a product identifier: valid package
+// URLs are purls, CPE 2.2 URIs and CPE 2.3 formatted strings are CPEs and
+// anything else is unknown.
+func ProductScheme(id string) Scheme {
+	lower := strings.ToLower(id)
+	switch {
+	case strings.HasPrefix(lower, "pkg:"):
+		if _, err := purl.FromString(id); err == nil {
+			return SchemePurl
+		}
+	case strings.HasPrefix(lower, "cpe:2.3:"), strings.HasPrefix(lower, "cpe:/"):
+		return SchemeCPE
+	}
+	return SchemeUnknown
+}
+
+// ComponentPurls returns the purls identifying a component: its ID when it
+// is a purl and its purl identifier.
+func ComponentPurls(c *vex.Component) []string {
+	return componentScheme(c, SchemePurl, vex.PURL)
+}
+
+// ComponentCPEs returns the CPEs identifying a component: its ID when it is
+// a CPE and its CPE 2.3 and 2.2 identifiers.
+func ComponentCPEs(c *vex.Component) []string {
+	return componentScheme(c, SchemeCPE, vex.CPE23, vex.CPE22)
+}
+
+// componentScheme returns the ID of the component if it uses a scheme and
+// the values of the identifiers of the specified types
+func componentScheme(c *vex.Component, scheme Scheme, types ...vex.IdentifierType) []string {
+	ids := []string{}
+	if c.ID != "" && ProductScheme(c.ID) == scheme {
+		ids = append(ids, c.ID)
+	}
+	for _, t := range types {
+		if id, ok := c.Identifiers[t]; ok && ProductScheme(id) == scheme {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestProductScheme(t *testing.T) {
+	for id, expected := range map[string]Scheme{
+		"pkg:apk/wolfi/bash@1.0.0":                        SchemePurl,
+		"pkg:oci/app@sha256%3Aabcd1234?tag=v1":            SchemePurl,
+		"PKG:npm/lodash@4.17.21":                          SchemePurl,
+		"pkg:":                                            SchemeUnknown,
+		"cpe:2.3:a:gnu:bash:5.0:*:*:*:*:*:*:*":            SchemeCPE,
+		"cpe:/a:gnu:bash:5.0":                             SchemeCPE,
+		"CPE:2.3:A:GNU:BASH:5.0:*:*:*:*:*:*:*":            SchemeCPE,
+		"cpe:bash":                                        SchemeUnknown,
+		"https://example.com/products/bash":               SchemeUnknown,
+		"sha256:ed6a5a4a4dc0567d3801da8d8fa4da0b5b82e6c0": SchemeUnknown,
+		"": SchemeUnknown,
+	} {
+		require.Equal(t, expected, ProductScheme(id), id)
+	}
+}
+
+func TestComponentIdentifiersByScheme(t *testing.T) {
+	c := &vex.Component{
+		ID: "pkg:apk/wolfi/bash@1.0.0",
+		Identifiers: map[vex.IdentifierType]string{
+			vex.PURL:  "pkg:apk/wolfi/bash@1.0.0?arch=x86_64",
+			vex.CPE23: "cpe:2.3:a:gnu:bash:1.0.0:*:*:*:*:*:*:*",
+			vex.CPE22: "not a cpe",
+		},
+	}
+	require.Equal(t, []string{"pkg:apk/wolfi/bash@1.0.0", "pkg:apk/wolfi/bash@1.0.0?arch=x86_64"}, ComponentPurls(c))
+	require.Equal(t, []string{"cpe:2.3:a:gnu:bash:1.0.0:*:*:*:*:*:*:*"}, ComponentCPEs(c))
+
+	// CPEs match regardless of case, but never against purls
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := testStatement("CVE-2023-0001", "", vex.StatusFixed, ts)
+	s.Products[0].Component = *c
+	doc := testDocument(ts, s)
+	require.Len(t, StatementsForProduct(doc, "CPE:2.3:A:GNU:BASH:1.0.0:*:*:*:*:*:*:*"), 1)
+	require.Empty(t, StatementsForProduct(doc, "cpe:2.3:a:gnu:bash:2.0.0:*:*:*:*:*:*:*"))
+}
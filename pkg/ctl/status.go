@@ -0,0 +1,437 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// statusSeverity ranks the VEX statuses by how much attention they require
+// from a consumer of the VEX data:
+//
+//   - affected: the product needs action, this is what CI gates should stop.
+//   - under_investigation: the impact is not known yet, so the product cannot
+//     be considered safe.
+//   - fixed and not_affected: no action is required. Fixed ranks higher as
+//     it records that the product was vulnerable at some point.
+var statusSeverity = map[vex.Status]int{
+	vex.StatusNotAffected:        1,
+	vex.StatusFixed:              2,
+	vex.StatusUnderInvestigation: 3,
+	vex.StatusAffected:           4,
+}
+
+// StatusSeverity returns the rank of a status in the total ordering vexctl
+// uses to compare statuses. From least to most severe: invalid (including
+// empty) statuses, not_affected, fixed, under_investigation and affected.
+// Invalid statuses rank lowest as they carry no information about the
+// product.
+func StatusSeverity(status vex.Status) int {
+	return statusSeverity[status]
+}
+
+// MoreSevere returns true if status a ranks higher than status b in the
+// ordering defined by StatusSeverity
+func MoreSevere(a, b vex.Status) bool {
+	return StatusSeverity(a) > StatusSeverity(b)
+}
+
+// effectiveStatements returns the latest statement about each vulnerability
+// that applies to a product across a set of documents. The returned map is
+// keyed by vulnerability name.
+func effectiveStatements(docs []*vex.VEX, productID string) map[string]vex.Statement {
+	return effectiveStatementsMatching(docs, productID, nil)
+}
+
+// effectiveStatementsMatching returns the effective statements about a
+// product like effectiveStatements, matching the products with a matcher.
+// A nil matcher uses DefaultProductMatcher, so all queries match products as
+// StatementsForProduct does.
+func effectiveStatementsMatching(docs []*vex.VEX, productID string, m ProductMatcher) map[string]vex.Statement {
+	if m == nil {
+		m = DefaultProductMatcher
+	}
+	// Copy the list to avoid reordering the caller's slice
+	sorted := make([]*vex.VEX, len(docs))
+	copy(sorted, docs)
+	sortDocumentsStable(sorted)
+
+	ss := []vex.Statement{}
+	for _, doc := range sorted {
+		for _, s := range doc.Statements { //nolint:gocritic // this IS supposed to copy
+			if !statementMatches(&s, productID, m) {
+				continue
+			}
+			ss = append(ss, cascadeTimestamp(doc, s))
+		}
+	}
+
+	// Statements are sorted by vulnerability and date. Ties keep the
+	// document order, so the latest statement always comes last.
+	vex.SortStatements(ss, time.Time{})
+
+	effective := map[string]vex.Statement{}
+	for _, s := range ss { //nolint:gocritic // this IS supposed to copy
+		effective[string(s.Vulnerability.Name)] = s
+	}
+	return effective
+}
+
+// sortDocumentsStable sorts documents by timestamp like vex.SortDocuments,
+// with the documents without one last, but keeps documents with the same
+// timestamp in their original order
+func sortDocumentsStable(docs []*vex.VEX) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := docs[i].Timestamp, docs[j].Timestamp
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+}
+
+// EffectiveStatuses returns the effective status of every vulnerability that
+// affects a product according to a set of documents. Statements are applied
+// in chronological order and the latest statement determines the status of
+// each vulnerability. The returned map is keyed by vulnerability name.
+// Products are matched as in StatementsForProduct.
+func EffectiveStatuses(docs []*vex.VEX, productID string) map[string]vex.Status {
+	return EffectiveStatusesWithOptions(docs, productID, &MatchOptions{})
+}
+
+// EffectiveStatusesWithOptions returns the effective statuses of the
+// vulnerabilities of a product like EffectiveStatuses, matching products
+// with the matcher in the options. Nil options use the regular rules.
+func EffectiveStatusesWithOptions(docs []*vex.VEX, productID string, opts *MatchOptions) map[string]vex.Status {
+	if opts == nil {
+		opts = &MatchOptions{}
+	}
+	statuses := map[string]vex.Status{}
+	for vuln, s := range effectiveStatementsMatching(docs, productID, opts.Matcher) { //nolint:gocritic // this IS supposed to copy
+		statuses[vuln] = s.Status
+	}
+	return statuses
+}
+
+// WorstEffectiveStatus computes the effective statuses of all vulnerabilities
+// in a product and returns the most severe one. The statuses are ranked by
+// how much attention they require: affected, then under_investigation and
+// finally fixed and not_affected, which require no action. If the documents
+// have no statements about the product, an empty status is returned.
+func WorstEffectiveStatus(docs []*vex.VEX, productID string) vex.Status {
+	var worst vex.Status
+	for _, status := range EffectiveStatuses(docs, productID) {
+		if MoreSevere(status, worst) {
+			worst = status
+		}
+	}
+	return worst
+}
+
+// latestMatching returns the latest of the effective statements that match
+// a vulnerability by name or alias, or nil if none match. Statements with the
+// same timestamp are resolved by the first vulnerability name in order, so
+// the result never depends on the map iteration order.
+func latestMatching(effective map[string]vex.Statement, vulnID string) *vex.Statement {
+	names := make([]string, 0, len(effective))
+	for name := range effective {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var latest *vex.Statement
+	for _, name := range names {
+		s := effective[name]
+		if !s.Vulnerability.Matches(vulnID) {
+			continue
+		}
+		if latest == nil || statementTime(&s).After(statementTime(latest)) {
+			latest = &s
+		}
+	}
+	return latest
+}
+
+// statementTime returns the timestamp of a statement, or the zero time when
+// it has none
+func statementTime(s *vex.Statement) time.Time {
+	if s.Timestamp == nil {
+		return time.Time{}
+	}
+	return *s.Timestamp
+}
+
+var (
+	// ErrUnderInvestigation is returned by IsAffected when the impact of
+	// the vulnerability in the product is still under investigation
+	ErrUnderInvestigation = errors.New("vulnerability is under investigation")
+
+	// ErrUnknownStatus is returned by IsAffected when the documents have no
+	// valid statement about the vulnerability and product
+	ErrUnknownStatus = errors.New("status of vulnerability is unknown")
+)
+
+// IsAffected reports whether a product is affected by a vulnerability
+// according to its effective status in a set of documents. It returns true
+// for affected and false for not_affected and fixed. When the status is
+// under_investigation it returns ErrUnderInvestigation, and when there is no
+// statement about the vulnerability and product (or its status is invalid)
+// it returns ErrUnknownStatus, so callers must decide how to treat unknowns
+// instead of silently taking them as safe. Vulnerabilities are matched by
+// any of their identifiers. When statements under different names match
+// (eg a CVE and its GHSA alias), the latest one determines the status.
+func IsAffected(docs []*vex.VEX, vulnID, productID string) (bool, error) {
+	found := latestMatching(effectiveStatements(docs, productID), vulnID)
+	if found == nil {
+		return false, ErrUnknownStatus
+	}
+
+	switch found.Status {
+	case vex.StatusAffected:
+		return true, nil
+	case vex.StatusNotAffected, vex.StatusFixed:
+		return false, nil
+	case vex.StatusUnderInvestigation:
+		return false, ErrUnderInvestigation
+	default:
+		return false, ErrUnknownStatus
+	}
+}
+
+// ProductsWithStatus returns the products in a document with at least one
+// vulnerability in a status, according to the effective statement about
+// each of their vulnerabilities (see EffectiveStatuses). Products are
+// returned in the order they first appear in the document. Callers can
+// compare the lists returned for different statuses to build their reports.
+func ProductsWithStatus(doc *vex.VEX, status vex.Status) []string {
+	products := []string{}
+	for _, product := range documentProducts(doc) {
+		for _, s := range EffectiveStatuses([]*vex.VEX{doc}, product) {
+			if s == status {
+				products = append(products, product)
+				break
+			}
+		}
+	}
+	return products
+}
+
+// ProductsNeedingAction returns the products in a document that still need
+// patching: those affected by at least one vulnerability whose effective
+// status has not been overridden by a later fixed or not_affected statement.
+func ProductsNeedingAction(doc *vex.VEX) []string {
+	return ProductsWithStatus(doc, vex.StatusAffected)
+}
+
+// PostureMap returns a compact snapshot of the effective status of every
+// vulnerability in every product of a document (see EffectiveStatuses). The
+// outer map is keyed by product ID and the inner one by vulnerability name.
+// The map can be serialized to JSON as is, for dashboards that don't need
+// the full document.
+func PostureMap(doc *vex.VEX) map[string]map[string]vex.Status {
+	posture := map[string]map[string]vex.Status{}
+	for _, product := range documentProducts(doc) {
+		posture[product] = EffectiveStatuses([]*vex.VEX{doc}, product)
+	}
+	return posture
+}
+
+// needsTriage returns true if a statement leaves a vulnerability pending
+// triage: it is under investigation or lacks the data its status requires.
+func needsTriage(s *vex.Statement) bool {
+	return s.Status == vex.StatusUnderInvestigation || s.Validate() != nil
+}
+
+// PendingTriage returns the statements in a document that leave a
+// vulnerability pending triage in any of its products. Only the effective
+// statement of each vulnerability and product is considered, so issues
+// already resolved by a later statement are not returned. The statements are
+// returned in the order of their vulnerabilities.
+func PendingTriage(doc *vex.VEX) []vex.Statement {
+	pending := []vex.Statement{}
+	seen := map[string]struct{}{}
+	for _, product := range documentProducts(doc) {
+		for _, s := range effectiveStatements([]*vex.VEX{doc}, product) { //nolint:gocritic // this IS supposed to copy
+			if !needsTriage(&s) {
+				continue
+			}
+			// Statements listing more than one product are only returned once
+			key := statementKey(s)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			pending = append(pending, s)
+		}
+	}
+
+	vex.SortStatements(pending, time.Time{})
+	return pending
+}
+
+// documentProducts returns the identifiers of the products in a document (see
+// productIdentifier) in the order they first appear
+func documentProducts(doc *vex.VEX) []string {
+	unique := uniqueProducts(doc)
+	products := make([]string, 0, len(unique))
+	for i := range unique {
+		products = append(products, productIdentifier(&unique[i]))
+	}
+	return products
+}
+
+// uniqueProducts returns the first occurrence of each product in a document,
+// telling them apart by their identifier. Products without an ID or purl
+// are skipped.
+func uniqueProducts(doc *vex.VEX) []vex.Product {
+	products := []vex.Product{}
+	seen := map[string]struct{}{}
+	for i := range doc.Statements {
+		for j := range doc.Statements[i].Products {
+			id := productIdentifier(&doc.Statements[i].Products[j])
+			if _, ok := seen[id]; ok || id == "" {
+				continue
+			}
+			seen[id] = struct{}{}
+			products = append(products, doc.Statements[i].Products[j])
+		}
+	}
+	return products
+}
+
+// PendingTriageFor returns the sorted names of the vulnerabilities whose
+// effective statement leaves them pending triage in a product according to
+// a set of documents.
+func PendingTriageFor(docs []*vex.VEX, productID string) []string {
+	vulns := []string{}
+	for vuln, s := range effectiveStatements(docs, productID) { //nolint:gocritic // this IS supposed to copy
+		if needsTriage(&s) {
+			vulns = append(vulns, vuln)
+		}
+	}
+	sort.Strings(vulns)
+	return vulns
+}
+
+// TriageProgress returns the fraction of the vulnerabilities in a list that
+// the document has triaged. A vulnerability is triaged when the document has
+// statements about it and, for all products, its effective statement does
+// not leave it pending triage (see PendingTriage). Vulnerabilities are
+// matched by any of their identifiers. An empty list is fully triaged.
+func TriageProgress(doc *vex.VEX, allVulns []string) float64 {
+	if len(allVulns) == 0 {
+		return 1
+	}
+
+	pending := PendingTriage(doc)
+	triaged := 0
+	for _, id := range allVulns {
+		if !hasVulnerability(doc.Statements, id) || hasVulnerability(pending, id) {
+			continue
+		}
+		triaged++
+	}
+	return float64(triaged) / float64(len(allVulns))
+}
+
+// TriageProgressFor returns the fraction of the vulnerabilities in a list
+// that have been triaged in a product according to a set of documents, that
+// is, those that have effective statements about the product and none of
+// them leaves them pending triage. Vulnerabilities are matched by any of
+// their identifiers, as in TriageProgress. An empty list is fully triaged.
+func TriageProgressFor(docs []*vex.VEX, productID string, allVulns []string) float64 {
+	if len(allVulns) == 0 {
+		return 1
+	}
+
+	effective := effectiveStatements(docs, productID)
+	triaged := 0
+	for _, id := range allVulns {
+		if isTriaged(effective, id) {
+			triaged++
+		}
+	}
+	return float64(triaged) / float64(len(allVulns))
+}
+
+// isTriaged returns true if a vulnerability has effective statements, matched
+// by any of its identifiers, and none of them leaves it pending triage
+func isTriaged(effective map[string]vex.Statement, id string) bool {
+	found := false
+	for _, s := range effective { //nolint:gocritic // this IS supposed to copy
+		if !s.Vulnerability.Matches(id) {
+			continue
+		}
+		if needsTriage(&s) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// hasVulnerability returns true if any of the statements is about a
+// vulnerability
+func hasVulnerability(statements []vex.Statement, id string) bool {
+	for i := range statements {
+		if statements[i].Vulnerability.Matches(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusUnknown is the status GroupByStatus buckets statements with empty or
+// invalid statuses under. It is not a valid VEX status.
+const StatusUnknown vex.Status = "unknown"
+
+// GroupByStatus returns the statements in a document bucketed by their status.
+// Statements keep their document order within each bucket. Statements with
+// an empty or invalid status are grouped under StatusUnknown.
+func GroupByStatus(doc *vex.VEX) map[vex.Status][]vex.Statement {
+	// Count the statements first to allocate each bucket only once
+	counts := map[vex.Status]int{}
+	for i := range doc.Statements {
+		counts[groupStatus(doc.Statements[i].Status)]++
+	}
+
+	groups := make(map[vex.Status][]vex.Statement, len(counts))
+	for status, n := range counts {
+		groups[status] = make([]vex.Statement, 0, n)
+	}
+	for i := range doc.Statements {
+		status := groupStatus(doc.Statements[i].Status)
+		groups[status] = append(groups[status], doc.Statements[i])
+	}
+	return groups
+}
+
+// groupStatus returns the bucket a status is grouped under
+func groupStatus(status vex.Status) vex.Status {
+	if !status.Valid() {
+		return StatusUnknown
+	}
+	return status
+}
+
+// Justifications counts how often each justification is used in the
+// not_affected statements of a document. Statements that are not_affected
+// but lack a machine readable justification, relying only on an impact
+// statement, are counted under the empty justification.
+func Justifications(doc *vex.VEX) map[vex.Justification]int {
+	counts := map[vex.Justification]int{}
+	for i := range doc.Statements {
+		if doc.Statements[i].Status != vex.StatusNotAffected {
+			continue
+		}
+		counts[doc.Statements[i].Justification]++
+	}
+	return counts
+}
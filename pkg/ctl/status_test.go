@@ -0,0 +1,400 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// testDocument returns a new document dated ts with the statements
+func testDocument(ts time.Time, statements ...vex.Statement) *vex.VEX {
+	doc := vex.New()
+	doc.Timestamp = &ts
+	doc.Statements = statements
+	return &doc
+}
+
+func TestEffectiveStatuses(t *testing.T) {
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	docs := []*vex.VEX{
+		testDocument(t2,
+			testStatement("CVE-2023-0001", product, vex.StatusNotAffected, t2),
+		),
+		testDocument(t1,
+			testStatement("CVE-2023-0001", product, vex.StatusUnderInvestigation, t1),
+			testStatement("CVE-2023-0002", product, vex.StatusFixed, t1),
+			testStatement("CVE-2023-0003", "pkg:apk/wolfi/git@1.0.0", vex.StatusAffected, t1),
+		),
+	}
+
+	require.Equal(t, map[string]vex.Status{
+		"CVE-2023-0001": vex.StatusNotAffected,
+		"CVE-2023-0002": vex.StatusFixed,
+	}, EffectiveStatuses(docs, product))
+
+	// The caller's slice must not be reordered
+	require.Equal(t, &t2, docs[0].Timestamp)
+
+	// Ties keep the document order, so the last of the latest documents
+	// wins. Enough documents are used to rule out the insertion sort of
+	// small slices.
+	tied := []*vex.VEX{}
+	for i := 0; i < 13; i++ {
+		switch {
+		case i == 11:
+			tied = append(tied, testDocument(t2, testStatement("CVE-2023-0001", product, vex.StatusAffected, t2)))
+		case i%2 == 1:
+			tied = append(tied, testDocument(t2, testStatement("CVE-2023-0001", product, vex.StatusNotAffected, t2)))
+		default:
+			tied = append(tied, testDocument(t1, testStatement("CVE-2023-0001", product, vex.StatusFixed, t1)))
+		}
+	}
+	require.Equal(t, map[string]vex.Status{"CVE-2023-0001": vex.StatusAffected}, EffectiveStatuses(tied, product))
+}
+
+func TestMoreSevere(t *testing.T) {
+	// Statuses from the least to the most severe
+	ordered := []vex.Status{
+		"cheese", vex.StatusNotAffected, vex.StatusFixed, vex.StatusUnderInvestigation, vex.StatusAffected,
+	}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			require.Equal(t, i > j, MoreSevere(a, b), "%s vs %s", a, b)
+		}
+	}
+
+	// All invalid statuses rank the same
+	require.False(t, MoreSevere("", "cheese"))
+	require.False(t, MoreSevere("cheese", ""))
+}
+
+func TestWorstEffectiveStatus(t *testing.T) {
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		docs     []*vex.VEX
+		expected vex.Status
+	}{
+		{"no statements", []*vex.VEX{testDocument(ts)}, ""},
+		{
+			"all clean",
+			[]*vex.VEX{testDocument(ts,
+				testStatement("CVE-2023-0001", product, vex.StatusNotAffected, ts),
+				testStatement("CVE-2023-0002", product, vex.StatusFixed, ts),
+			)},
+			vex.StatusFixed,
+		},
+		{
+			"under investigation",
+			[]*vex.VEX{testDocument(ts,
+				testStatement("CVE-2023-0001", product, vex.StatusNotAffected, ts),
+				testStatement("CVE-2023-0002", product, vex.StatusUnderInvestigation, ts),
+			)},
+			vex.StatusUnderInvestigation,
+		},
+		{
+			"affected dominates",
+			[]*vex.VEX{testDocument(ts,
+				testStatement("CVE-2023-0001", product, vex.StatusNotAffected, ts),
+				testStatement("CVE-2023-0002", product, vex.StatusFixed, ts),
+				testStatement("CVE-2023-0003", product, vex.StatusAffected, ts),
+				testStatement("CVE-2023-0004", product, vex.StatusUnderInvestigation, ts),
+			)},
+			vex.StatusAffected,
+		},
+		{
+			"fixed later",
+			[]*vex.VEX{testDocument(ts,
+				testStatement("CVE-2023-0001", product, vex.StatusAffected, ts),
+				testStatement("CVE-2023-0001", product, vex.StatusFixed, ts.Add(time.Hour)),
+			)},
+			vex.StatusFixed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, WorstEffectiveStatus(tc.docs, product))
+		})
+	}
+}
+
+func TestIsAffected(t *testing.T) {
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	aliased := testStatement("GHSA-abcd-efgh-ijkl", product, vex.StatusAffected, ts)
+	aliased.Vulnerability.Aliases = []vex.VulnerabilityID{"CVE-2023-0006"}
+	docs := []*vex.VEX{testDocument(ts,
+		testStatement("CVE-2023-0001", product, vex.StatusAffected, ts),
+		testStatement("CVE-2023-0002", product, vex.StatusNotAffected, ts),
+		testStatement("CVE-2023-0003", product, vex.StatusFixed, ts),
+		testStatement("CVE-2023-0004", product, vex.StatusUnderInvestigation, ts),
+		testStatement("CVE-2023-0005", product, vex.Status("cheese"), ts),
+		aliased,
+	)}
+
+	for _, tc := range []struct {
+		vuln     string
+		expected bool
+		err      error
+	}{
+		{"CVE-2023-0001", true, nil},
+		{"CVE-2023-0002", false, nil},
+		{"CVE-2023-0003", false, nil},
+		{"CVE-2023-0004", false, ErrUnderInvestigation},
+		{"CVE-2023-0005", false, ErrUnknownStatus},
+		{"CVE-2023-0006", true, nil},
+		{"CVE-2023-9999", false, ErrUnknownStatus},
+	} {
+		t.Run(tc.vuln, func(t *testing.T) {
+			affected, err := IsAffected(docs, tc.vuln, product)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.expected, affected)
+		})
+	}
+
+	// Statements under different names that alias each other are resolved
+	// by their timestamps
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	cve := testStatement("CVE-2023-1", product, vex.StatusAffected, ts)
+	cve.Vulnerability.Aliases = []vex.VulnerabilityID{"GHSA-aaaa-bbbb-cccc"}
+	ghsa := testStatement("GHSA-aaaa-bbbb-cccc", product, vex.StatusFixed, t2)
+	ghsa.Vulnerability.Aliases = []vex.VulnerabilityID{"CVE-2023-1"}
+	docs = []*vex.VEX{testDocument(ts, cve, ghsa)}
+	for i := 0; i < 100; i++ {
+		for _, id := range []string{"CVE-2023-1", "GHSA-aaaa-bbbb-cccc"} {
+			affected, err := IsAffected(docs, id, product)
+			require.NoError(t, err)
+			require.False(t, affected)
+		}
+	}
+}
+
+func TestProductsNeedingAction(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@1.0.0"
+	curl := "pkg:apk/wolfi/curl@1.0.0"
+
+	doc := testDocument(t1,
+		// bash was affected but has been fixed since
+		testStatement("CVE-2023-0001", bash, vex.StatusAffected, t1),
+		testStatement("CVE-2023-0001", bash, vex.StatusFixed, t2),
+		// git is fixed for one vulnerability but affected by another
+		testStatement("CVE-2023-0001", git, vex.StatusFixed, t1),
+		testStatement("CVE-2023-0002", git, vex.StatusAffected, t1),
+		// curl went from not_affected to affected
+		testStatement("CVE-2023-0003", curl, vex.StatusNotAffected, t1),
+		testStatement("CVE-2023-0003", curl, vex.StatusAffected, t2),
+	)
+
+	require.Equal(t, []string{git, curl}, ProductsNeedingAction(doc))
+	require.Equal(t, []string{bash, git}, ProductsWithStatus(doc, vex.StatusFixed))
+	require.Empty(t, ProductsWithStatus(doc, vex.StatusNotAffected))
+}
+
+func TestGroupByStatus(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", product, vex.StatusFixed, ts),
+		testStatement("CVE-2023-0002", product, vex.StatusAffected, ts),
+		testStatement("CVE-2023-0003", product, vex.StatusFixed, ts),
+		testStatement("CVE-2023-0004", product, "", ts),
+		testStatement("CVE-2023-0005", product, "cheese", ts),
+		testStatement("CVE-2023-0006", product, vex.StatusNotAffected, ts),
+	)
+
+	groups := GroupByStatus(doc)
+	require.Len(t, groups, 4)
+
+	names := func(ss []vex.Statement) []string {
+		ret := []string{}
+		for i := range ss {
+			ret = append(ret, string(ss[i].Vulnerability.Name))
+		}
+		return ret
+	}
+	require.Equal(t, []string{"CVE-2023-0001", "CVE-2023-0003"}, names(groups[vex.StatusFixed]))
+	require.Equal(t, []string{"CVE-2023-0002"}, names(groups[vex.StatusAffected]))
+	require.Equal(t, []string{"CVE-2023-0006"}, names(groups[vex.StatusNotAffected]))
+	require.Equal(t, []string{"CVE-2023-0004", "CVE-2023-0005"}, names(groups[StatusUnknown]))
+	require.Empty(t, groups[vex.StatusUnderInvestigation])
+
+	require.Empty(t, GroupByStatus(testDocument(ts)))
+}
+
+func TestJustifications(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	unjustified := testStatement("CVE-2023-0003", product, vex.StatusNotAffected, ts)
+	unjustified.Justification = ""
+	unjustified.ImpactStatement = "The vulnerable function is never called"
+	inlinePath := testStatement("CVE-2023-0004", product, vex.StatusNotAffected, ts)
+	inlinePath.Justification = vex.VulnerableCodeNotInExecutePath
+
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", product, vex.StatusNotAffected, ts),
+		testStatement("CVE-2023-0002", product, vex.StatusNotAffected, ts),
+		unjustified,
+		inlinePath,
+		testStatement("CVE-2023-0005", product, vex.StatusAffected, ts),
+	)
+
+	require.Equal(t, map[vex.Justification]int{
+		vex.ComponentNotPresent:            2,
+		vex.VulnerableCodeNotInExecutePath: 1,
+		"":                                 1,
+	}, Justifications(doc))
+
+	// Lint flags the unjustified statement
+	findings := Lint(doc)
+	require.Len(t, findings, 1)
+	require.Equal(t, LevelWarning, findings[0].Level)
+	require.Equal(t, "/statements/2/justification", findings[0].Pointer)
+}
+
+func TestPendingTriage(t *testing.T) {
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@2.0.0"
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	noAction := testStatement("CVE-2023-0003", bash, vex.StatusAffected, t1)
+	noAction.ActionStatement = ""
+
+	doc := testDocument(t1,
+		// Resolved later on
+		testStatement("CVE-2023-0001", bash, vex.StatusUnderInvestigation, t1),
+		testStatement("CVE-2023-0001", bash, vex.StatusFixed, t2),
+		// Still under investigation
+		testStatement("CVE-2023-0002", bash, vex.StatusUnderInvestigation, t1),
+		// Missing required data
+		noAction,
+		// Resolved for bash but not for git
+		testStatement("CVE-2023-0004", git, vex.StatusUnderInvestigation, t1),
+		testStatement("CVE-2023-0004", bash, vex.StatusNotAffected, t2),
+	)
+
+	vulns := []string{}
+	for _, s := range PendingTriage(doc) {
+		vulns = append(vulns, string(s.Vulnerability.Name)+" "+s.Products[0].ID)
+	}
+	require.Equal(t, []string{
+		"CVE-2023-0002 " + bash,
+		"CVE-2023-0003 " + bash,
+		"CVE-2023-0004 " + git,
+	}, vulns)
+
+	later := testDocument(t2, testStatement("CVE-2023-0002", bash, vex.StatusNotAffected, t2))
+	require.Equal(t, []string{"CVE-2023-0002", "CVE-2023-0003"}, PendingTriageFor([]*vex.VEX{doc}, bash))
+	require.Equal(t, []string{"CVE-2023-0003"}, PendingTriageFor([]*vex.VEX{doc, later}, bash))
+	require.Equal(t, []string{"CVE-2023-0004"}, PendingTriageFor([]*vex.VEX{doc, later}, git))
+	require.Empty(t, PendingTriageFor([]*vex.VEX{doc}, "pkg:apk/wolfi/curl@1.0.0"))
+}
+
+func TestTriageProgress(t *testing.T) {
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@2.0.0"
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	aliased := testStatement("GHSA-abcd-efgh-ijkl", bash, vex.StatusFixed, t1)
+	aliased.Vulnerability.Aliases = []vex.VulnerabilityID{"CVE-2023-0004"}
+	doc := testDocument(t1,
+		testStatement("CVE-2023-0001", bash, vex.StatusUnderInvestigation, t1),
+		testStatement("CVE-2023-0001", bash, vex.StatusFixed, t2),
+		testStatement("CVE-2023-0002", bash, vex.StatusUnderInvestigation, t1),
+		testStatement("CVE-2023-0003", bash, vex.StatusNotAffected, t1),
+		testStatement("CVE-2023-0003", git, vex.StatusUnderInvestigation, t1),
+		aliased,
+	)
+
+	for _, tc := range []struct {
+		name     string
+		vulns    []string
+		expected float64
+	}{
+		{"empty list", []string{}, 1},
+		{"fully triaged", []string{"CVE-2023-0001", "CVE-2023-0004"}, 1},
+		{"fully pending", []string{"CVE-2023-0002", "CVE-2023-0003"}, 0},
+		{"no statements", []string{"CVE-2023-9999"}, 0},
+		{"mixed", []string{"CVE-2023-0001", "CVE-2023-0002", "CVE-2023-0003", "CVE-2023-9999"}, 0.25},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.expected, TriageProgress(doc, tc.vulns), 0.0001)
+		})
+	}
+
+	docs := []*vex.VEX{doc}
+	all := []string{"CVE-2023-0001", "CVE-2023-0002", "CVE-2023-0003", "CVE-2023-9999"}
+	require.InDelta(t, 0.5, TriageProgressFor(docs, bash, all), 0.0001)
+	require.InDelta(t, 0, TriageProgressFor(docs, git, all), 0.0001)
+	require.InDelta(t, 1, TriageProgressFor(docs, git, []string{}), 0.0001)
+
+	// Vulnerabilities triaged under an alias count as triaged, the GHSA
+	// statement is the only one about CVE-2023-0004
+	require.InDelta(t, 1, TriageProgress(doc, []string{"CVE-2023-0004"}), 0.0001)
+	require.InDelta(t, 1, TriageProgressFor(docs, bash, []string{"CVE-2023-0004"}), 0.0001)
+	require.InDelta(t, 1, TriageProgressFor(docs, bash, []string{"GHSA-abcd-efgh-ijkl"}), 0.0001)
+
+	// ... but not when a statement under another name is pending
+	pending := testStatement("CVE-2023-0004", bash, vex.StatusUnderInvestigation, t1)
+	doc.Statements = append(doc.Statements, pending)
+	require.InDelta(t, 0, TriageProgress(doc, []string{"CVE-2023-0004"}), 0.0001)
+	require.InDelta(t, 0, TriageProgressFor(docs, bash, []string{"CVE-2023-0004"}), 0.0001)
+}
+
+func TestPostureMap(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(t1,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusAffected, t1),
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, t2),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusUnderInvestigation, t1),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/curl@1.0.0", vex.StatusNotAffected, t1),
+	)
+
+	posture := PostureMap(doc)
+	require.Equal(t, map[string]map[string]vex.Status{
+		"pkg:apk/wolfi/bash@1.0.0": {
+			"CVE-2023-0001": vex.StatusFixed,
+			"CVE-2023-0002": vex.StatusUnderInvestigation,
+		},
+		"pkg:apk/wolfi/curl@1.0.0": {
+			"CVE-2023-0002": vex.StatusNotAffected,
+		},
+	}, posture)
+
+	data, err := json.Marshal(posture)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"pkg:apk/wolfi/bash@1.0.0": {"CVE-2023-0001": "fixed", "CVE-2023-0002": "under_investigation"},
+		"pkg:apk/wolfi/curl@1.0.0": {"CVE-2023-0002": "not_affected"}
+	}`, string(data))
+
+	require.Empty(t, PostureMap(testDocument(t1)))
+
+	// Products identified only by their purl are included
+	purlOnly := testStatement("CVE-2023-0003", "", vex.StatusAffected, t1)
+	purlOnly.Products[0].Identifiers = map[vex.IdentifierType]string{vex.PURL: "pkg:apk/wolfi/git@1.0.0"}
+	doc = testDocument(t1, purlOnly)
+	require.Equal(t, map[string]map[string]vex.Status{
+		"pkg:apk/wolfi/git@1.0.0": {"CVE-2023-0003": vex.StatusAffected},
+	}, PostureMap(doc))
+	require.Equal(t, []string{"pkg:apk/wolfi/git@1.0.0"}, ProductsNeedingAction(doc))
+}
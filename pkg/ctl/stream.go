@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// MergeStream merges documents read one at a time, for corpora too large to
+// load in memory. next returns the reader of each document and io.EOF when
+// there are no more. Documents are parsed with ParseDocument, so any format
+// it supports can be streamed.
+//
+// Unlike Merge, which keeps every statement, MergeStream only keeps the
+// latest statement about each vulnerability and product, so memory is
+// bounded by the size of the result and not by the size of the corpus. The
+// merged document has a statement per vulnerability and product with the
+// same effective statuses a Merge of the corpus has (see EffectiveStatuses).
+// Statements without products are kept like Merge does, the latest one about
+// each vulnerability.
+// Statements with the same timestamp are resolved in favor of the one read
+// last. The document ID, author, supplier and filters are taken from the
+// options as in Merge; aliases are not reconciled. Nil options use the
+// defaults.
+func MergeStream(next func() (io.Reader, error), opts *MergeOptions) (*vex.VEX, error) {
+	if opts == nil {
+		opts = &MergeOptions{}
+	}
+	type entry struct {
+		statement vex.Statement
+		supplier  string
+	}
+	latest := map[string]*entry{}
+	keys := []string{}
+
+	ids := []string{}
+	supplier, mixedSuppliers := "", false
+	for i := 0; ; i++ {
+		r, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading document #%d: %w", i, err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading document #%d: %w", i, err)
+		}
+		doc, err := ParseDocument(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing document #%d: %w", i, err)
+		}
+
+		ids = append(ids, mergedDocumentRef(doc, i))
+		if i == 0 {
+			supplier = doc.Supplier
+		} else if doc.Supplier != supplier {
+			mixedSuppliers = true
+		}
+
+		for _, s := range doc.Statements { //nolint:gocritic // this IS supposed to copy
+			if !matchesAny(len(opts.Vulnerabilities), func(j int) bool {
+				return s.Vulnerability.Matches(opts.Vulnerabilities[j])
+			}) {
+				continue
+			}
+			if s.Timestamp == nil {
+				if doc.Timestamp == nil {
+					return nil, errors.New("unable to cascade timestamp from doc to timeless statement")
+				}
+				s.Timestamp = doc.Timestamp
+			}
+
+			keep := func(key string, products []vex.Product) {
+				e, ok := latest[key]
+				if ok && e.statement.Timestamp.After(*s.Timestamp) {
+					return
+				}
+				if !ok {
+					e = &entry{}
+					latest[key] = e
+					keys = append(keys, key)
+				}
+				e.statement = s
+				e.statement.Products = products
+				e.supplier = doc.Supplier
+			}
+
+			// Statements without products are kept as in Merge, unless
+			// filtering by product. They are keyed by their vulnerability.
+			if len(s.Products) == 0 {
+				if len(opts.Products) == 0 {
+					keep(string(s.Vulnerability.Name), nil)
+				}
+				continue
+			}
+			for j := range s.Products {
+				p := s.Products[j]
+				if !matchesAny(len(opts.Products), func(k int) bool {
+					return p.Matches(opts.Products[k], "")
+				}) {
+					continue
+				}
+				keep(string(s.Vulnerability.Name)+"\x00"+productIdentifier(&p), []vex.Product{p})
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("at least one vex document is required to merge")
+	}
+
+	newDoc := NewDocument()
+	newDoc.ID = opts.DocumentID
+	if newDoc.ID == "" {
+		newDoc.ID = mergedDocumentID(ids)
+	}
+	if opts.Author != "" {
+		newDoc.Author = opts.Author
+	}
+	if opts.AuthorRole != "" {
+		newDoc.AuthorRole = opts.AuthorRole
+	}
+	newDoc.Supplier = opts.Supplier
+	if newDoc.Supplier == "" && !mixedSuppliers {
+		newDoc.Supplier = supplier
+	}
+
+	ss := make([]vex.Statement, 0, len(keys))
+	for _, key := range keys {
+		e := latest[key]
+		if e.supplier != "" && e.supplier != newDoc.Supplier {
+			e.statement.Products = productsWithSupplier(e.statement.Products, e.supplier)
+		}
+		ss = append(ss, e.statement)
+	}
+
+	var ts time.Time
+	if newDoc.Timestamp != nil {
+		ts = *newDoc.Timestamp
+	}
+	vex.SortStatements(ss, ts)
+	newDoc.Statements = ss
+	return &newDoc, nil
+}
+
+// matchesAny returns true if any of the n elements of a filter matches, or
+// if the filter is empty
+func matchesAny(n int, matches func(int) bool) bool {
+	if n == 0 {
+		return true
+	}
+	for i := 0; i < n; i++ {
+		if matches(i) {
+			return true
+		}
+	}
+	return false
+}
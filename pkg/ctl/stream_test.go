@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// writeMergeCorpus writes n documents with statements about the same set of
+// vulnerabilities and products changing status over time, and returns their
+// paths in a shuffled order
+func writeMergeCorpus(t testing.TB, dir string, n int) []string {
+	statuses := []vex.Status{
+		vex.StatusUnderInvestigation, vex.StatusAffected, vex.StatusFixed, vex.StatusNotAffected,
+	}
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	paths := make([]string, n)
+	for i := 0; i < n; i++ {
+		ts := base.Add(time.Duration(i) * time.Hour)
+		doc := testDocument(ts)
+		doc.ID = fmt.Sprintf("doc-%d", i)
+		for v := 0; v < 10; v++ {
+			s := testStatement(
+				fmt.Sprintf("CVE-2023-%04d", v), fmt.Sprintf("pkg:apk/wolfi/package-%d@1.0.0", (i+v)%5),
+				statuses[(i*v)%len(statuses)], ts,
+			)
+			s.Products = append(s.Products, vex.Product{
+				Component: vex.Component{ID: fmt.Sprintf("pkg:apk/wolfi/package-%d@1.0.0", (i+v+1)%5)},
+			})
+			doc.Statements = append(doc.Statements, s)
+		}
+		var b bytes.Buffer
+		require.NoError(t, doc.ToJSON(&b))
+		// Write the files in an order that is not chronological
+		paths[(i*7)%n] = filepath.Join(dir, fmt.Sprintf("doc-%05d.json", i))
+		require.NoError(t, os.WriteFile(paths[(i*7)%n], b.Bytes(), os.FileMode(0o644)))
+	}
+	return paths
+}
+
+// fileStream returns a MergeStream iterator over a list of files
+func fileStream(t testing.TB, paths []string) func() (io.Reader, error) {
+	i := 0
+	return func() (io.Reader, error) {
+		if i == len(paths) {
+			return nil, io.EOF
+		}
+		data, err := os.ReadFile(paths[i])
+		require.NoError(t, err)
+		i++
+		return bytes.NewReader(data), nil
+	}
+}
+
+func TestMergeStream(t *testing.T) {
+	paths := writeMergeCorpus(t, t.TempDir(), 12)
+
+	streamed, err := MergeStream(fileStream(t, paths), &MergeOptions{})
+	require.NoError(t, err)
+
+	docs := []*vex.VEX{}
+	for _, path := range paths {
+		doc, err := vex.Open(path)
+		require.NoError(t, err)
+		docs = append(docs, doc)
+	}
+	impl := defaultVexCtlImplementation{}
+	merged, err := impl.Merge(context.Background(), &MergeOptions{}, docs)
+	require.NoError(t, err)
+
+	// Both merges have the same ID and effective statuses, but the
+	// streamed one only has a statement per vulnerability and product
+	require.Equal(t, merged.ID, streamed.ID)
+	require.Len(t, streamed.Statements, 50)
+	for p := 0; p < 5; p++ {
+		product := fmt.Sprintf("pkg:apk/wolfi/package-%d@1.0.0", p)
+		require.Equal(t,
+			EffectiveStatuses([]*vex.VEX{merged}, product),
+			EffectiveStatuses([]*vex.VEX{streamed}, product),
+			product,
+		)
+	}
+
+	// Nil options use the defaults
+	defaults, err := MergeStream(fileStream(t, paths), nil)
+	require.NoError(t, err)
+	require.Equal(t, streamed.ID, defaults.ID)
+	require.Len(t, defaults.Statements, 50)
+
+	// Filters are applied as in Merge
+	filtered, err := MergeStream(fileStream(t, paths), &MergeOptions{
+		Products:        []string{"pkg:apk/wolfi/package-1@1.0.0"},
+		Vulnerabilities: []string{"CVE-2023-0001", "CVE-2023-0002"},
+	})
+	require.NoError(t, err)
+	require.Len(t, filtered.Statements, 2)
+
+	_, err = MergeStream(fileStream(t, nil), &MergeOptions{})
+	require.Error(t, err)
+
+	// Statements without products are kept like Merge does, the latest
+	// about each vulnerability, unless filtering by product
+	dir := t.TempDir()
+	productless := []string{}
+	for i, status := range []vex.Status{vex.StatusUnderInvestigation, vex.StatusAffected} {
+		ts := time.Date(2023, 1, 1+i, 0, 0, 0, 0, time.UTC)
+		s := testStatement("CVE-2023-0001", "", status, ts)
+		s.Products = nil
+		var b bytes.Buffer
+		require.NoError(t, testDocument(ts, s).ToJSON(&b))
+		productless = append(productless, filepath.Join(dir, fmt.Sprintf("doc-%d.json", i)))
+		require.NoError(t, os.WriteFile(productless[i], b.Bytes(), os.FileMode(0o644)))
+	}
+	kept, err := MergeStream(fileStream(t, productless), nil)
+	require.NoError(t, err)
+	require.Len(t, kept.Statements, 1)
+	require.Equal(t, vex.StatusAffected, kept.Statements[0].Status)
+	require.Empty(t, kept.Statements[0].Products)
+
+	kept, err = MergeStream(fileStream(t, productless), &MergeOptions{Products: []string{"pkg:apk/wolfi/bash@1.0.0"}})
+	require.NoError(t, err)
+	require.Empty(t, kept.Statements)
+}
+
+func BenchmarkMerge(b *testing.B) {
+	paths := writeMergeCorpus(b, b.TempDir(), 500)
+	b.Run("in-memory", func(b *testing.B) {
+		b.ReportAllocs()
+		impl := defaultVexCtlImplementation{}
+		for i := 0; i < b.N; i++ {
+			docs := []*vex.VEX{}
+			for _, path := range paths {
+				doc, err := vex.Open(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				docs = append(docs, doc)
+			}
+			if _, err := impl.Merge(context.Background(), &MergeOptions{}, docs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := MergeStream(fileStream(b, paths), &MergeOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,404 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	purl "github.com/package-url/packageurl-go"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// NormalizeOptions control how documents are normalized
+type NormalizeOptions struct {
+	// PreserveOrder keeps the statements in the order they were authored
+	// instead of sorting them by vulnerability and date. Useful to get
+	// reviewable diffs of documents kept in version control.
+	PreserveOrder bool
+
+	// Log records the changes made to the statements, if set
+	Log *TransformLog
+}
+
+// Normalize returns a copy of the document in its normal form: product purls
+// and vulnerability identifiers are canonicalized (see CanonicalPurl and
+// CanonicalVulnerabilityID), products repeated in a statement are collapsed
+// (see DedupProducts), duplicate statements are removed (see Deduplicate)
+// and the rest are sorted by vulnerability and date, unless the options ask
+// to preserve their order. Nil options use the defaults.
+func Normalize(doc *vex.VEX, opts *NormalizeOptions) *vex.VEX {
+	if opts == nil {
+		opts = &NormalizeOptions{}
+	}
+	canonical := &vex.VEX{
+		Metadata:   doc.Metadata,
+		Statements: make([]vex.Statement, 0, len(doc.Statements)),
+	}
+	for i, s := range doc.Statements { //nolint:gocritic // this IS supposed to copy
+		c := canonicalizePurls(s)
+		if statementKey(c) != statementKey(s) {
+			opts.Log.add(TransformEntry{
+				Action: TransformCanonicalizedPurls, Statement: i,
+				Vulnerability: string(s.Vulnerability.Name),
+			})
+		}
+		c.Vulnerability = canonicalizeVulnerability(s.Vulnerability)
+		if c.Vulnerability.Name != s.Vulnerability.Name || !slices.Equal(c.Vulnerability.Aliases, s.Vulnerability.Aliases) {
+			opts.Log.add(TransformEntry{
+				Action: TransformCanonicalizedVulnerability, Statement: i,
+				Vulnerability: string(s.Vulnerability.Name),
+			})
+		}
+		if n := DedupProducts(&c); n > 0 {
+			opts.Log.add(TransformEntry{
+				Action: TransformRemovedDuplicateProducts, Statement: i,
+				Vulnerability: string(s.Vulnerability.Name),
+				Detail:        fmt.Sprintf("removed %d duplicate products", n),
+			})
+		}
+		canonical.Statements = append(canonical.Statements, c)
+	}
+
+	newDoc := deduplicate(canonical, EqualityKey(), opts.Log)
+	if opts.PreserveOrder {
+		return newDoc
+	}
+
+	var ts time.Time
+	if doc.Timestamp != nil {
+		ts = *doc.Timestamp
+	}
+	vex.SortStatements(newDoc.Statements, ts)
+	return newDoc
+}
+
+// CanonicalPurl returns the canonical form of a purl as defined by the purl
+// spec: the type is lowercased, the namespace and name are adjusted according
+// to the rules of each package type (for example, npm and pypi names are
+// lowercased) and the qualifiers are sorted with their values percent-encoded
+// uniformly. Parts of the purl that are case sensitive are not modified.
+func CanonicalPurl(s string) (string, error) {
+	p, err := purl.FromString(s)
+	if err != nil {
+		return "", fmt.Errorf("parsing purl: %w", err)
+	}
+	return p.ToString(), nil
+}
+
+var (
+	cveID  = regexp.MustCompile(`(?i)^cve-\d{4}-\d{4,}$`)
+	ghsaID = regexp.MustCompile(`(?i)^ghsa(-[0-9a-z]{4}){3}$`)
+)
+
+// CanonicalVulnerabilityID returns the canonical form of a vulnerability
+// identifier. Surrounding space is trimmed and, for the schemes whose format
+// is known, the identifier is cased as its issuer publishes it: CVE IDs are
+// uppercased and GHSA IDs get an uppercase prefix and lowercase body. Other
+// identifiers are only trimmed, as their casing may be significant.
+func CanonicalVulnerabilityID(id string) string {
+	id = strings.TrimSpace(id)
+	switch {
+	case cveID.MatchString(id):
+		return strings.ToUpper(id)
+	case ghsaID.MatchString(id):
+		return "GHSA" + strings.ToLower(id[4:])
+	}
+	return id
+}
+
+// canonicalizeVulnerability returns a copy of a vulnerability with its name
+// and aliases canonicalized
+func canonicalizeVulnerability(v vex.Vulnerability) vex.Vulnerability { //nolint:gocritic // this IS supposed to copy
+	v.Name = vex.VulnerabilityID(CanonicalVulnerabilityID(string(v.Name)))
+	if v.Aliases == nil {
+		return v
+	}
+	aliases := make([]vex.VulnerabilityID, len(v.Aliases))
+	for i, a := range v.Aliases {
+		aliases[i] = vex.VulnerabilityID(CanonicalVulnerabilityID(string(a)))
+	}
+	v.Aliases = aliases
+	return v
+}
+
+// canonicalizePurls returns a copy of a statement with the purls identifying
+// its products and subcomponents in their canonical form. Identifiers that
+// are not valid purls are left as is.
+func canonicalizePurls(s vex.Statement) vex.Statement { //nolint:gocritic // this IS supposed to copy
+	products := make([]vex.Product, len(s.Products))
+	for i, p := range s.Products { //nolint:gocritic // this IS supposed to copy
+		p.Component = canonicalizeComponent(p.Component)
+		subs := make([]vex.Subcomponent, len(p.Subcomponents))
+		for j, sc := range p.Subcomponents {
+			sc.Component = canonicalizeComponent(sc.Component)
+			subs[j] = sc
+		}
+		if p.Subcomponents == nil {
+			subs = nil
+		}
+		p.Subcomponents = subs
+		products[i] = p
+	}
+	if s.Products == nil {
+		products = nil
+	}
+	s.Products = products
+	return s
+}
+
+// DedupProducts collapses the products listed more than once in a statement,
+// as those resulting from expanding CSAF product relationships, and returns
+// how many were removed. Products are the same when they have the same ID
+// or, if they have none, the same purl identifier. The first entry of each
+// product is kept in its position, with the subcomponents, identifiers and
+// hashes of its repetitions added to it.
+func DedupProducts(s *vex.Statement) int {
+	products := make([]vex.Product, 0, len(s.Products))
+	index := map[string]int{}
+	for i := range s.Products {
+		p := s.Products[i]
+		id := productIdentifier(&p)
+		j, ok := index[id]
+		if !ok || id == "" {
+			index[id] = len(products)
+			products = append(products, p)
+			continue
+		}
+		products[j] = mergeProducts(products[j], p)
+	}
+
+	removed := len(s.Products) - len(products)
+	if removed > 0 {
+		s.Products = products
+	}
+	return removed
+}
+
+// mergeProducts returns a copy of product a with the subcomponents,
+// identifiers and hashes of product b that it lacks
+func mergeProducts(a, b vex.Product) vex.Product { //nolint:gocritic // this IS supposed to copy
+	subs := map[string]struct{}{}
+	for i := range a.Subcomponents {
+		subs[a.Subcomponents[i].ID] = struct{}{}
+	}
+	for i := range b.Subcomponents {
+		if _, ok := subs[b.Subcomponents[i].ID]; ok {
+			continue
+		}
+		subs[b.Subcomponents[i].ID] = struct{}{}
+		a.Subcomponents = append(slices.Clip(a.Subcomponents), b.Subcomponents[i])
+	}
+
+	for t, v := range b.Identifiers {
+		if _, ok := a.Identifiers[t]; ok {
+			continue
+		}
+		identifiers := make(map[vex.IdentifierType]string, len(a.Identifiers)+1)
+		for k, v := range a.Identifiers {
+			identifiers[k] = v
+		}
+		identifiers[t] = v
+		a.Identifiers = identifiers
+	}
+	for algo, h := range b.Hashes {
+		if _, ok := a.Hashes[algo]; ok {
+			continue
+		}
+		hashes := make(map[vex.Algorithm]vex.Hash, len(a.Hashes)+1)
+		for k, v := range a.Hashes {
+			hashes[k] = v
+		}
+		hashes[algo] = h
+		a.Hashes = hashes
+	}
+	return a
+}
+
+// canonicalizeComponent returns a copy of a component with its purls in
+// canonical form
+func canonicalizeComponent(c vex.Component) vex.Component { //nolint:gocritic // this IS supposed to copy
+	canonical := func(id string) string {
+		if !strings.HasPrefix(id, "pkg:") {
+			return id
+		}
+		if cp, err := CanonicalPurl(id); err == nil {
+			return cp
+		}
+		return id
+	}
+
+	c.ID = canonical(c.ID)
+	if id, ok := c.Identifiers[vex.PURL]; ok {
+		identifiers := make(map[vex.IdentifierType]string, len(c.Identifiers))
+		for k, v := range c.Identifiers {
+			identifiers[k] = v
+		}
+		identifiers[vex.PURL] = canonical(id)
+		c.Identifiers = identifiers
+	}
+	return c
+}
+
+// ChunkStatements returns a copy of the document where the statements listing
+// more than maxProducts products are split into as many statements as needed
+// to keep each one under the limit, for consumers that cannot handle large
+// statements. The chunks keep the data of the original statement and the
+// order of its products, so the resulting document is equivalent to the
+// original one. If maxProducts is less than one, no statement is split.
+func ChunkStatements(doc *vex.VEX, maxProducts int) *vex.VEX {
+	newDoc := &vex.VEX{
+		Metadata:   doc.Metadata,
+		Statements: make([]vex.Statement, 0, len(doc.Statements)),
+	}
+	for _, s := range doc.Statements { //nolint:gocritic // this IS supposed to copy
+		if maxProducts < 1 || len(s.Products) <= maxProducts {
+			newDoc.Statements = append(newDoc.Statements, s)
+			continue
+		}
+		products := s.Products
+		for start := 0; start < len(products); start += maxProducts {
+			end := start + maxProducts
+			if end > len(products) {
+				end = len(products)
+			}
+			// Clip the capacity so appending to a chunk does not
+			// overwrite the products of the next one
+			s.Products = products[start:end:end]
+			newDoc.Statements = append(newDoc.Statements, s)
+		}
+	}
+	return newDoc
+}
+
+// KeepStatuses returns a copy of the document with only the statements in one
+// of the listed statuses, in their original order. Distributing only the
+// affected and under_investigation statements, for example, keeps documents
+// small when consumers only need to act on those.
+func KeepStatuses(doc *vex.VEX, statuses ...vex.Status) *vex.VEX {
+	newDoc := &vex.VEX{
+		Metadata:   doc.Metadata,
+		Statements: []vex.Statement{},
+	}
+	for i := range doc.Statements {
+		if slices.Contains(statuses, doc.Statements[i].Status) {
+			newDoc.Statements = append(newDoc.Statements, doc.Statements[i])
+		}
+	}
+	return newDoc
+}
+
+// Deduplicate returns a copy of the document with duplicate statements
+// removed. Statements are duplicates when they carry the same data and
+// describe the same vulnerability, even if it is keyed by a different
+// identifier, as long as one lists the other as an alias.
+//
+// When statements are collapsed, the first one is kept and the aliases of the
+// rest (including their names) are added to its list of aliases so no
+// vulnerability identifiers are lost.
+func Deduplicate(doc *vex.VEX) *vex.VEX {
+	return DeduplicateWithKey(doc, EqualityKey())
+}
+
+// DeduplicateWithKey works like Deduplicate but compares the statements
+// using a key function, usually built with EqualityKey, so callers can choose
+// which fields make statements duplicates. The vulnerability is always
+// compared by its identifiers, so the key is computed with it cleared.
+func DeduplicateWithKey(doc *vex.VEX, key func(vex.Statement) string) *vex.VEX {
+	return deduplicate(doc, key, nil)
+}
+
+// deduplicate implements DeduplicateWithKey, recording the removed
+// statements in the log
+func deduplicate(doc *vex.VEX, key func(vex.Statement) string, log *TransformLog) *vex.VEX {
+	newDoc := &vex.VEX{
+		Metadata:   doc.Metadata,
+		Statements: []vex.Statement{},
+	}
+
+	// Index the kept statements by their data, minus the vulnerability
+	index := map[string][]int{}
+
+	// Index in the input of each kept statement, for the log
+	originals := []int{}
+
+	for j, s := range doc.Statements { //nolint:gocritic // this IS supposed to copy
+		data := s
+		data.Vulnerability = vex.Vulnerability{}
+		k := key(data)
+		found := false
+		for _, i := range index[k] {
+			if !sameVulnerability(&newDoc.Statements[i].Vulnerability, &s.Vulnerability) {
+				continue
+			}
+			newDoc.Statements[i].Vulnerability = mergeAliases(
+				newDoc.Statements[i].Vulnerability, s.Vulnerability,
+			)
+			log.add(TransformEntry{
+				Action: TransformRemovedDuplicate, Statement: j,
+				Vulnerability: string(s.Vulnerability.Name),
+				Detail:        fmt.Sprintf("duplicate of statement #%d", originals[i]),
+			})
+			found = true
+			break
+		}
+		if found {
+			continue
+		}
+		index[k] = append(index[k], len(newDoc.Statements))
+		originals = append(originals, j)
+		newDoc.Statements = append(newDoc.Statements, s)
+	}
+	return newDoc
+}
+
+// sameVulnerability returns true if two vulnerabilities are the same one,
+// that is if any of the identifiers of one of them matches the other.
+func sameVulnerability(a, b *vex.Vulnerability) bool {
+	for _, id := range vulnerabilityIdentifiers(b) {
+		if a.Matches(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// vulnerabilityIdentifiers returns all the identifiers of a vulnerability
+func vulnerabilityIdentifiers(v *vex.Vulnerability) []string {
+	ids := []string{}
+	if v.ID != "" {
+		ids = append(ids, v.ID)
+	}
+	if v.Name != "" {
+		ids = append(ids, string(v.Name))
+	}
+	for _, a := range v.Aliases {
+		ids = append(ids, string(a))
+	}
+	return ids
+}
+
+// mergeAliases returns a copy of vulnerability a with the name and aliases of
+// vulnerability b added to its aliases.
+func mergeAliases(a, b vex.Vulnerability) vex.Vulnerability {
+	seen := map[vex.VulnerabilityID]struct{}{a.Name: {}}
+	var aliases []vex.VulnerabilityID
+	for _, list := range [][]vex.VulnerabilityID{a.Aliases, {b.Name}, b.Aliases} {
+		for _, id := range list {
+			if _, ok := seen[id]; ok || id == "" {
+				continue
+			}
+			seen[id] = struct{}{}
+			aliases = append(aliases, id)
+		}
+	}
+	a.Aliases = aliases
+	return a
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestDeduplicate(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+
+	cveKeyed := testStatement("CVE-2023-0001", product, vex.StatusFixed, ts)
+	ghsaKeyed := testStatement("GHSA-abcd-efgh-ijkl", product, vex.StatusFixed, ts)
+	ghsaKeyed.Vulnerability.Aliases = []vex.VulnerabilityID{"CVE-2023-0001", "GO-2023-0001"}
+
+	for _, tc := range []struct {
+		name       string
+		statements []vex.Statement
+		expected   []vex.Statement
+	}{
+		{
+			name:       "no duplicates",
+			statements: []vex.Statement{cveKeyed, testStatement("CVE-2023-0002", product, vex.StatusFixed, ts)},
+			expected:   []vex.Statement{cveKeyed, testStatement("CVE-2023-0002", product, vex.StatusFixed, ts)},
+		},
+		{
+			name:       "exact duplicates",
+			statements: []vex.Statement{cveKeyed, cveKeyed},
+			expected:   []vex.Statement{cveKeyed},
+		},
+		{
+			name:       "different status",
+			statements: []vex.Statement{cveKeyed, testStatement("CVE-2023-0001", product, vex.StatusAffected, ts)},
+			expected:   []vex.Statement{cveKeyed, testStatement("CVE-2023-0001", product, vex.StatusAffected, ts)},
+		},
+		{
+			name:       "aliased duplicates keep all identifiers",
+			statements: []vex.Statement{cveKeyed, ghsaKeyed},
+			expected: []vex.Statement{
+				func() vex.Statement {
+					s := cveKeyed
+					s.Vulnerability.Aliases = []vex.VulnerabilityID{"GHSA-abcd-efgh-ijkl", "GO-2023-0001"}
+					return s
+				}(),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := vex.New()
+			doc.Statements = tc.statements
+			newDoc := Deduplicate(&doc)
+			require.Equal(t, tc.expected, newDoc.Statements)
+		})
+	}
+
+	// The original document must not be modified
+	require.Empty(t, cveKeyed.Vulnerability.Aliases)
+}
+
+func TestChunkStatements(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	large := testStatement("CVE-2023-0001", "pkg:apk/wolfi/package-0@1.0.0", vex.StatusNotAffected, ts)
+	for i := 1; i < 5000; i++ {
+		large.Products = append(large.Products, vex.Product{
+			Component: vex.Component{ID: fmt.Sprintf("pkg:apk/wolfi/package-%d@1.0.0", i)},
+		})
+	}
+	small := testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)
+	doc := testDocument(ts, large, small)
+
+	chunked := ChunkStatements(doc, 1000)
+	require.Len(t, chunked.Statements, 6)
+	products := []vex.Product{}
+	for _, s := range chunked.Statements[:5] {
+		require.Len(t, s.Products, 1000)
+		require.Equal(t, large.Vulnerability, s.Vulnerability)
+		require.Equal(t, large.Justification, s.Justification)
+		products = append(products, s.Products...)
+	}
+	require.Equal(t, large.Products, products)
+	require.Equal(t, small, chunked.Statements[5])
+
+	// The chunks cover the same products with the same status
+	product := "pkg:apk/wolfi/package-4321@1.0.0"
+	require.Equal(t, EffectiveStatuses([]*vex.VEX{doc}, product), EffectiveStatuses([]*vex.VEX{chunked}, product))
+
+	require.Len(t, ChunkStatements(doc, 3000).Statements, 3)
+	require.Len(t, ChunkStatements(doc, 0).Statements, 2)
+}
+
+func TestKeepStatuses(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", bash, vex.StatusFixed, ts),
+		testStatement("CVE-2023-0002", bash, vex.StatusUnderInvestigation, ts),
+		testStatement("CVE-2023-0003", bash, vex.StatusNotAffected, ts),
+		testStatement("CVE-2023-0004", bash, vex.StatusAffected, ts),
+	)
+
+	kept := KeepStatuses(doc, vex.StatusAffected, vex.StatusUnderInvestigation)
+	require.Len(t, kept.Statements, 2)
+	require.Equal(t, doc.Statements[1], kept.Statements[0])
+	require.Equal(t, doc.Statements[3], kept.Statements[1])
+	require.Equal(t, doc.Metadata, kept.Metadata)
+	require.Len(t, doc.Statements, 4)
+
+	require.Empty(t, KeepStatuses(doc).Statements)
+}
+
+func TestNormalize(t *testing.T) {
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	doc := testDocument(t1,
+		testStatement("CVE-2023-0002", product, vex.StatusFixed, t2),
+		testStatement("CVE-2023-0001", product, vex.StatusAffected, t2),
+		testStatement("CVE-2023-0002", product, vex.StatusUnderInvestigation, t1),
+		testStatement("CVE-2023-0002", product, vex.StatusFixed, t2),
+	)
+	original := append([]vex.Statement{}, doc.Statements...)
+
+	names := func(d *vex.VEX) []string {
+		ret := []string{}
+		for i := range d.Statements {
+			ret = append(ret, string(d.Statements[i].Vulnerability.Name)+" "+string(d.Statements[i].Status))
+		}
+		return ret
+	}
+
+	require.Equal(t, []string{
+		"CVE-2023-0001 affected",
+		"CVE-2023-0002 under_investigation",
+		"CVE-2023-0002 fixed",
+	}, names(Normalize(doc, &NormalizeOptions{})))
+
+	require.Equal(t, []string{
+		"CVE-2023-0002 fixed",
+		"CVE-2023-0001 affected",
+		"CVE-2023-0002 under_investigation",
+	}, names(Normalize(doc, &NormalizeOptions{PreserveOrder: true})))
+
+	// Nil options use the defaults
+	require.Equal(t, names(Normalize(doc, &NormalizeOptions{})), names(Normalize(doc, nil)))
+
+	// The original document is not modified
+	require.Equal(t, original, doc.Statements)
+}
+
+func TestDedupProducts(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	git := "pkg:apk/wolfi/git@1.0.0"
+
+	s := testStatement("CVE-2023-0001", bash, vex.StatusFixed, ts)
+	s.Products = append(s.Products,
+		vex.Product{Component: vex.Component{ID: git}},
+		vex.Product{
+			Component:     vex.Component{ID: bash, Hashes: map[vex.Algorithm]vex.Hash{vex.SHA256: "abc"}},
+			Subcomponents: []vex.Subcomponent{{Component: vex.Component{ID: "pkg:apk/wolfi/readline@8.0"}}},
+		},
+		vex.Product{Component: vex.Component{ID: git}},
+	)
+
+	require.Equal(t, 2, DedupProducts(&s))
+	require.Len(t, s.Products, 2)
+	require.Equal(t, bash, s.Products[0].ID)
+	require.Equal(t, vex.Hash("abc"), s.Products[0].Hashes[vex.SHA256])
+	require.Len(t, s.Products[0].Subcomponents, 1)
+	require.Equal(t, git, s.Products[1].ID)
+	require.Equal(t, 0, DedupProducts(&s))
+
+	// Normalize collapses the repeated products, logging it
+	dup := testStatement("CVE-2023-0002", bash, vex.StatusFixed, ts)
+	dup.Products = append(dup.Products, vex.Product{Component: vex.Component{ID: "pkg:APK/wolfi/bash@1.0.0"}})
+	log := &TransformLog{}
+	normal := Normalize(testDocument(ts, dup), &NormalizeOptions{Log: log})
+	require.Len(t, normal.Statements[0].Products, 1)
+	require.Len(t, log.Entries, 2)
+	require.Equal(t, TransformRemovedDuplicateProducts, log.Entries[1].Action)
+}
+
+func TestLoadPreservesOrder(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(ts)
+	for _, vuln := range []string{"CVE-2023-0003", "CVE-2023-0001", "CVE-2023-0002"} {
+		doc.Statements = append(doc.Statements, testStatement(vuln, "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts))
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, doc.ToJSON(&b))
+	parsed, err := ParseDocument(b.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, doc.Statements, parsed.Statements)
+
+	var b2 bytes.Buffer
+	require.NoError(t, parsed.ToJSON(&b2))
+	require.Equal(t, b.String(), b2.String())
+}
+
+func TestCanonicalPurl(t *testing.T) {
+	for _, tc := range []struct {
+		purl     string
+		expected string
+	}{
+		{"pkg:NPM/Lodash@4.17.21", "pkg:npm/lodash@4.17.21"},
+		{"pkg:PyPI/Django_Rest@3.0", "pkg:pypi/django-rest@3.0"},
+		{"pkg:GitHub/OpenVEX/VexCtl@v0.2.0", "pkg:github/openvex/vexctl@v0.2.0"},
+		// Maven names are case sensitive
+		{"pkg:MAVEN/org.Apache/Log4j@2.0", "pkg:maven/org.Apache/Log4j@2.0"},
+		// Qualifiers are sorted and encoded uniformly
+		{"pkg:oci/app@sha256:abcd?tag=1&Arch=amd64", "pkg:oci/app@sha256%3Aabcd?arch=amd64&tag=1"},
+	} {
+		got, err := CanonicalPurl(tc.purl)
+		require.NoError(t, err, tc.purl)
+		require.Equal(t, tc.expected, got, tc.purl)
+	}
+
+	_, err := CanonicalPurl("https://example.com/app")
+	require.Error(t, err)
+}
+
+func TestNormalizeCanonicalizesPurls(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	upper := testStatement("CVE-2023-0001", "pkg:NPM/Lodash@4.17.21", vex.StatusFixed, ts)
+	upper.Products[0].Subcomponents = []vex.Subcomponent{{Component: vex.Component{ID: "pkg:PyPI/Django_Rest@3.0"}}}
+	upper.Products[0].Identifiers = map[vex.IdentifierType]string{vex.PURL: "pkg:NPM/Lodash@4.17.21"}
+	lower := testStatement("CVE-2023-0001", "pkg:npm/lodash@4.17.21", vex.StatusFixed, ts)
+	lower.Products[0].Subcomponents = []vex.Subcomponent{{Component: vex.Component{ID: "pkg:pypi/django-rest@3.0"}}}
+	lower.Products[0].Identifiers = map[vex.IdentifierType]string{vex.PURL: "pkg:npm/lodash@4.17.21"}
+
+	doc := testDocument(ts, upper, lower)
+	newDoc := Normalize(doc, &NormalizeOptions{})
+
+	// Once canonicalized, both statements are duplicates
+	require.Len(t, newDoc.Statements, 1)
+	require.Equal(t, lower, newDoc.Statements[0])
+
+	// The original document is not modified
+	require.Equal(t, "pkg:NPM/Lodash@4.17.21", doc.Statements[0].Products[0].ID)
+	require.Equal(t, "pkg:NPM/Lodash@4.17.21", doc.Statements[0].Products[0].Identifiers[vex.PURL])
+	require.Equal(t, "pkg:PyPI/Django_Rest@3.0", doc.Statements[0].Products[0].Subcomponents[0].ID)
+
+	// Purl matching already compares the canonicalizable parts
+	require.NotEmpty(t, doc.Matches("CVE-2023-0001", "pkg:npm/lodash@4.17.21", nil))
+}
+
+func TestCanonicalVulnerabilityID(t *testing.T) {
+	for id, expected := range map[string]string{
+		"CVE-2021-1234":        "CVE-2021-1234",
+		"cve-2021-1234":        "CVE-2021-1234",
+		" Cve-2021-123456\n":   "CVE-2021-123456",
+		"ghsa-ABCD-efgh-IJKL":  "GHSA-abcd-efgh-ijkl",
+		"GHSA-abcd-efgh-ijkl":  "GHSA-abcd-efgh-ijkl",
+		"go-2023-1234":         "go-2023-1234",
+		"RHSA-2023:1234":       "RHSA-2023:1234",
+		"cve-2021":             "cve-2021",
+		"https://nvd.nist.gov": "https://nvd.nist.gov",
+	} {
+		require.Equal(t, expected, CanonicalVulnerabilityID(id), id)
+	}
+}
+
+func TestNormalizeCanonicalizesVulnerabilities(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+	lower := testStatement("cve-2023-0001", product, vex.StatusFixed, ts)
+	lower.Vulnerability.Aliases = []vex.VulnerabilityID{"ghsa-ABCD-efgh-ijkl", "go-2023-0001"}
+
+	log := &TransformLog{}
+	newDoc := Normalize(testDocument(ts, lower), &NormalizeOptions{Log: log})
+	require.Equal(t, vex.Vulnerability{
+		Name:    "CVE-2023-0001",
+		Aliases: []vex.VulnerabilityID{"GHSA-abcd-efgh-ijkl", "go-2023-0001"},
+	}, newDoc.Statements[0].Vulnerability)
+	require.Equal(t, vex.VulnerabilityID("cve-2023-0001"), lower.Vulnerability.Name)
+	require.Len(t, log.Entries, 1)
+	require.Equal(t, TransformCanonicalizedVulnerability, log.Entries[0].Action)
+
+	// Index lookups canonicalize the queries
+	idx := NewIndex(newDoc)
+	require.Len(t, idx.StatementsByVulnerability("cve-2023-0001"), 1)
+	require.Len(t, idx.StatementsByVulnerability("GHSA-ABCD-EFGH-IJKL"), 1)
+	require.Empty(t, idx.StatementsByVulnerability("GO-2023-0001"))
+}
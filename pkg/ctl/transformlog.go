@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Actions recorded in a TransformLog
+const (
+	// TransformRemovedDuplicate records a statement dropped as a duplicate
+	// of another one
+	TransformRemovedDuplicate = "removed_duplicate"
+
+	// TransformCanonicalizedPurls records a statement whose purls were
+	// rewritten in their canonical form
+	TransformCanonicalizedPurls = "canonicalized_purls"
+
+	// TransformCanonicalizedVulnerability records a statement whose
+	// vulnerability identifiers were rewritten in their canonical form
+	TransformCanonicalizedVulnerability = "canonicalized_vulnerability"
+
+	// TransformRemovedDuplicateProducts records a statement that listed
+	// some of its products more than once
+	TransformRemovedDuplicateProducts = "removed_duplicate_products"
+
+	// TransformRenamedVulnerability records a statement whose vulnerability
+	// was renamed to the name of one of its aliases
+	TransformRenamedVulnerability = "renamed_vulnerability"
+)
+
+// TransformEntry is an action performed on a statement by a transformation
+type TransformEntry struct {
+	// Action is one of the Transform* constants
+	Action string `json:"action"`
+
+	// Statement is the index of the statement in the transformation input.
+	// When merging, the input is the list of statements collected from all
+	// the documents, in order.
+	Statement int `json:"statement"`
+
+	// Vulnerability is the name of the vulnerability in the statement
+	Vulnerability string `json:"vulnerability,omitempty"`
+
+	// Detail describes the action in human readable form
+	Detail string `json:"detail,omitempty"`
+}
+
+// TransformLog records the actions performed by Normalize, Deduplicate and
+// Merge when set in their options. A nil log records nothing, so logging
+// is opt-in.
+type TransformLog struct {
+	Entries []TransformEntry
+}
+
+// add records an entry in the log
+func (tl *TransformLog) add(entry TransformEntry) { //nolint:gocritic // entries are small
+	if tl == nil {
+		return
+	}
+	tl.Entries = append(tl.Entries, entry)
+}
+
+// WriteJSONL writes the entries of the log as JSON Lines, one entry per line
+func (tl *TransformLog) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for i := range tl.Entries {
+		if err := enc.Encode(&tl.Entries[i]); err != nil {
+			return fmt.Errorf("writing log entry: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestTransformLog(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := "pkg:apk/wolfi/bash@1.0.0"
+
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", product, vex.StatusFixed, ts),
+		testStatement("CVE-2023-0002", "pkg:APK/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+		testStatement("CVE-2023-0001", product, vex.StatusFixed, ts),
+	)
+
+	log := &TransformLog{}
+	newDoc := Normalize(doc, &NormalizeOptions{PreserveOrder: true, Log: log})
+	require.Len(t, newDoc.Statements, 2)
+	require.Equal(t, []TransformEntry{
+		{Action: TransformCanonicalizedPurls, Statement: 1, Vulnerability: "CVE-2023-0002"},
+		{Action: TransformRemovedDuplicate, Statement: 2, Vulnerability: "CVE-2023-0001", Detail: "duplicate of statement #0"},
+	}, log.Entries)
+
+	var b bytes.Buffer
+	require.NoError(t, log.WriteJSONL(&b))
+	require.Equal(t, `{"action":"canonicalized_purls","statement":1,"vulnerability":"CVE-2023-0002"}
+{"action":"removed_duplicate","statement":2,"vulnerability":"CVE-2023-0001","detail":"duplicate of statement #0"}
+`, b.String())
+
+	// A nil log records nothing
+	require.Equal(t, newDoc, Normalize(doc, &NormalizeOptions{PreserveOrder: true}))
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"fmt"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// UpdateStatement applies an update function to the statements of a document
+// selected by a match function and returns how many were modified. Updates
+// are applied to copies of the statements which are validated before being
+// written back, so if any update fails or leaves a statement invalid, an
+// error is returned and the document is not modified. When statements are
+// updated, the document and the updated statements are marked as last
+// updated now.
+func UpdateStatement(
+	doc *vex.VEX, match func(vex.Statement) bool, update func(*vex.Statement) error,
+) (int, error) {
+	updated := map[int]vex.Statement{}
+	for i := range doc.Statements {
+		if !match(doc.Statements[i]) {
+			continue
+		}
+		s := doc.Statements[i]
+		if err := update(&s); err != nil {
+			return 0, fmt.Errorf("updating statement #%d: %w", i, err)
+		}
+		if err := s.Validate(); err != nil {
+			return 0, fmt.Errorf("statement #%d is invalid after the update: %w", i, err)
+		}
+		updated[i] = s
+	}
+
+	if len(updated) == 0 {
+		return 0, nil
+	}
+
+	now := TimeSource()
+	for i, s := range updated { //nolint:gocritic // this IS supposed to copy
+		s.LastUpdated = &now
+		doc.Statements[i] = s
+	}
+	doc.LastUpdated = &now
+	return len(updated), nil
+}
+
+// AddOptions control how AddStatementWithOptions adds statements
+type AddOptions struct {
+	// NoAutoTimestamp keeps statements without a timestamp undated, so
+	// they take the one of the document. Importers that set their own
+	// dates use it to avoid recording the import time.
+	NoAutoTimestamp bool
+}
+
+// AddStatement validates a statement and appends it to a document. If the
+// statement has no timestamp, it is dated with the current time as returned
+// by TimeSource, so the document records when the decision was made.
+func AddStatement(doc *vex.VEX, s vex.Statement) error { //nolint:gocritic // this IS supposed to copy
+	return AddStatementWithOptions(doc, s, &AddOptions{})
+}
+
+// AddStatementWithOptions works like AddStatement using the options. Nil
+// options use the defaults.
+func AddStatementWithOptions(doc *vex.VEX, s vex.Statement, opts *AddOptions) error { //nolint:gocritic // this IS supposed to copy
+	if opts == nil {
+		opts = &AddOptions{}
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("invalid statement: %w", err)
+	}
+	if s.Timestamp == nil && !opts.NoAutoTimestamp {
+		now := TimeSource()
+		s.Timestamp = &now
+	}
+	doc.Statements = append(doc.Statements, s)
+	return nil
+}
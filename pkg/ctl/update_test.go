@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestUpdateStatement(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bash := "pkg:apk/wolfi/bash@1.0.0"
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", bash, vex.StatusUnderInvestigation, ts),
+		testStatement("CVE-2023-0002", bash, vex.StatusUnderInvestigation, ts),
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/git@2.0.0", vex.StatusUnderInvestigation, ts),
+	)
+	isCVE1 := func(s vex.Statement) bool { return s.Vulnerability.Matches("CVE-2023-0001") } //nolint:gocritic // this IS supposed to copy
+
+	// Updates leaving statements invalid are rejected
+	n, err := UpdateStatement(doc, isCVE1, func(s *vex.Statement) error {
+		s.Status = vex.StatusNotAffected
+		return nil
+	})
+	require.Error(t, err)
+	require.Zero(t, n)
+	require.Equal(t, vex.StatusUnderInvestigation, doc.Statements[0].Status)
+	require.Nil(t, doc.LastUpdated)
+
+	// Errors from the update function are returned
+	_, err = UpdateStatement(doc, isCVE1, func(*vex.Statement) error { return errors.New("nope") })
+	require.ErrorContains(t, err, "nope")
+
+	n, err = UpdateStatement(doc, isCVE1, func(s *vex.Statement) error {
+		s.Status = vex.StatusNotAffected
+		s.Justification = vex.VulnerableCodeNotPresent
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, vex.StatusNotAffected, doc.Statements[0].Status)
+	require.Equal(t, vex.StatusUnderInvestigation, doc.Statements[1].Status)
+	require.Equal(t, vex.StatusNotAffected, doc.Statements[2].Status)
+	require.NotNil(t, doc.LastUpdated)
+	require.Equal(t, doc.LastUpdated, doc.Statements[0].LastUpdated)
+	require.Nil(t, doc.Statements[1].LastUpdated)
+
+	n, err = UpdateStatement(doc, func(vex.Statement) bool { return false }, nil)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestAddStatement(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	defer func(original func() time.Time) { TimeSource = original }(TimeSource)
+	TimeSource = func() time.Time { return now }
+
+	doc := testDocument(ts)
+	undated := testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)
+	undated.Timestamp = nil
+
+	// Undated statements are dated now, dated ones keep their timestamp
+	require.NoError(t, AddStatement(doc, undated))
+	require.NoError(t, AddStatement(doc, testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts)))
+	require.Len(t, doc.Statements, 2)
+	require.Equal(t, now, *doc.Statements[0].Timestamp)
+	require.Equal(t, ts, *doc.Statements[1].Timestamp)
+	require.Nil(t, undated.Timestamp)
+
+	// Importers can opt out
+	require.NoError(t, AddStatementWithOptions(doc, undated, &AddOptions{NoAutoTimestamp: true}))
+	require.Nil(t, doc.Statements[2].Timestamp)
+	require.NoError(t, AddStatementWithOptions(doc, undated, nil))
+	require.Equal(t, now, *doc.Statements[3].Timestamp)
+
+	// Invalid statements are not added
+	invalid := testStatement("CVE-2023-0003", "pkg:apk/wolfi/bash@1.0.0", vex.StatusAffected, ts)
+	invalid.ActionStatement = ""
+	require.Error(t, AddStatement(doc, invalid))
+	require.Len(t, doc.Statements, 4)
+}
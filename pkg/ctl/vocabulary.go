@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+// VocabularyMapper translates the OpenVEX statuses and justifications into
+// the terms used by an output format. Exporters take a mapper in their
+// options so callers can override the translation for consumers expecting
+// nonstandard values.
+type VocabularyMapper interface {
+	Status(vex.Status) string
+	Justification(vex.Justification) string
+}
+
+// Vocabulary is a VocabularyMapper backed by translation tables. Values
+// missing from the tables are emitted as their OpenVEX names.
+type Vocabulary struct {
+	Statuses       map[vex.Status]string
+	Justifications map[vex.Justification]string
+}
+
+// Status returns the term for a status
+func (v *Vocabulary) Status(status vex.Status) string {
+	if term, ok := v.Statuses[status]; ok {
+		return term
+	}
+	return string(status)
+}
+
+// Justification returns the term for a justification
+func (v *Vocabulary) Justification(justification vex.Justification) string {
+	if term, ok := v.Justifications[justification]; ok {
+		return term
+	}
+	return string(justification)
+}
+
+// OpenVEXVocabulary emits the statuses and justifications as defined in the
+// OpenVEX spec. It is the default of the exporters.
+var OpenVEXVocabulary VocabularyMapper = &Vocabulary{}
+
+// CSAFVocabulary emits the statuses as CSAF product status groups and the
+// justifications as CSAF flag labels.
+var CSAFVocabulary VocabularyMapper = &Vocabulary{
+	Statuses: map[vex.Status]string{
+		vex.StatusNotAffected:        "known_not_affected",
+		vex.StatusAffected:           "known_affected",
+		vex.StatusFixed:              "fixed",
+		vex.StatusUnderInvestigation: "under_investigation",
+	},
+}
+
+// ExportOptions control how documents are exported to other formats
+type ExportOptions struct {
+	// Vocabulary translates the statuses and justifications. Defaults to
+	// OpenVEXVocabulary.
+	Vocabulary VocabularyMapper
+}
+
+// vocabulary returns the mapper set in the options or the default one
+func (opts *ExportOptions) vocabulary() VocabularyMapper {
+	if opts == nil || opts.Vocabulary == nil {
+		return OpenVEXVocabulary
+	}
+	return opts.Vocabulary
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openvex/go-vex/pkg/vex"
+)
+
+func TestVocabulary(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := testDocument(ts,
+		testStatement("CVE-2023-0001", "pkg:apk/wolfi/bash@1.0.0", vex.StatusNotAffected, ts),
+		testStatement("CVE-2023-0002", "pkg:apk/wolfi/bash@1.0.0", vex.StatusFixed, ts),
+	)
+
+	for _, tc := range []struct {
+		name     string
+		opts     *ExportOptions
+		expected []string
+	}{
+		{
+			name:     "default",
+			opts:     &ExportOptions{},
+			expected: []string{"not_affected,component_not_present", "fixed,"},
+		},
+		{
+			name:     "csaf",
+			opts:     &ExportOptions{Vocabulary: CSAFVocabulary},
+			expected: []string{"known_not_affected,component_not_present", "fixed,"},
+		},
+		{
+			name: "custom",
+			opts: &ExportOptions{Vocabulary: &Vocabulary{
+				Statuses:       map[vex.Status]string{vex.StatusFixed: "resolved"},
+				Justifications: map[vex.Justification]string{vex.ComponentNotPresent: "code_not_present"},
+			}},
+			expected: []string{"not_affected,code_not_present", "resolved,"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			require.NoError(t, ToCSVWithOptions(doc, &b, tc.opts))
+			rows := strings.Split(strings.TrimSpace(b.String()), "\n")
+			require.Len(t, rows, 3)
+			for i, expected := range tc.expected {
+				require.Contains(t, rows[i+1], expected)
+			}
+		})
+	}
+
+	// Exporters emit the custom terms in their output
+	var b bytes.Buffer
+	require.NoError(t, ToSARIFSuppressionsWithOptions(doc, &b, &ExportOptions{
+		Vocabulary: &Vocabulary{Statuses: map[vex.Status]string{vex.StatusFixed: "resolved"}},
+	}))
+	require.Contains(t, b.String(), "CVE-2023-0002 is resolved in pkg:apk/wolfi/bash@1.0.0")
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The OpenVEX Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ctl
+
+// WarningKind classifies the recoverable problems found when loading data
+type WarningKind string
+
+const (
+	// WarningZeroTimestamp flags documents loaded without a date, which
+	// sort before any other document
+	WarningZeroTimestamp WarningKind = "zero_timestamp"
+
+	// WarningSkippedStatus flags entries skipped because their status
+	// cannot be translated to VEX
+	WarningSkippedStatus WarningKind = "skipped_status"
+
+	// WarningCSAFCategory flags CSAF documents imported as VEX that don't
+	// follow the CSAF VEX profile
+	WarningCSAFCategory WarningKind = "csaf_category"
+
+	// WarningUnknownSpecVersion flags OpenVEX documents targeting a spec
+	// version vexctl does not know, which are parsed as the current one
+	WarningUnknownSpecVersion WarningKind = "unknown_spec_version"
+)
+
+// Warning is a recoverable problem found when loading data. Loading goes on
+// after a warning, but the result may not be what the caller expects.
+type Warning struct {
+	Kind    WarningKind
+	Message string
+}
+
+// warnFunc returns a function calling a warning callback, or doing nothing
+// if the callback is not set
+func warnFunc(callback func(Warning)) func(WarningKind, string) {
+	return func(kind WarningKind, message string) {
+		if callback != nil {
+			callback(Warning{Kind: kind, Message: message})
+		}
+	}
+}